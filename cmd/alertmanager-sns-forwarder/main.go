@@ -1,10 +1,8 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"flag"
-	"io"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,8 +12,15 @@ import (
 
 	"github.com/maks3201/sns-alert-service/config"
 	"github.com/maks3201/sns-alert-service/internal/alertmanager"
+	"github.com/maks3201/sns-alert-service/internal/auth"
 	"github.com/maks3201/sns-alert-service/internal/aws"
+	"github.com/maks3201/sns-alert-service/internal/delivery"
+	"github.com/maks3201/sns-alert-service/internal/heartbeat"
+	"github.com/maks3201/sns-alert-service/internal/selfmon"
+	"github.com/maks3201/sns-alert-service/internal/silence"
 	health "github.com/maks3201/sns-alert-service/internal/status"
+	"github.com/maks3201/sns-alert-service/internal/tracing"
+	"github.com/maks3201/sns-alert-service/internal/web"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 )
@@ -24,47 +29,88 @@ func main() {
 	log.SetFormatter(&log.JSONFormatter{})
 
 	configFilePath := flag.String("config", "config/config.yaml", "Path to the configuration file")
+	listenAddress := flag.String("web.listen-address", "", "Address to listen on for alerts, overrides web.listen_address")
+	adminListenAddress := flag.String("web.admin-listen-address", "", "Separate address for metrics, status and admin APIs, overrides web.admin_listen_address")
 	flag.Parse()
 
 	cfg := config.LoadConfig(*configFilePath)
+	if *listenAddress != "" {
+		cfg.Web.ListenAddress = *listenAddress
+	}
+	if *adminListenAddress != "" {
+		cfg.Web.AdminListenAddress = *adminListenAddress
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
 
 	awsClient, err := aws.InitSNSClient(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize AWS client: %v", err)
 	}
 
-	alertHandler := alertmanager.NewHandler(cfg, awsClient)
+	silences, err := silence.NewStore(cfg.Silences.File, time.Duration(cfg.Silences.RetentionSeconds)*time.Second)
+	if err != nil {
+		log.Fatalf("Failed to load silences: %v", err)
+	}
 
-	http.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
-		health.HealthHandler(w, r, awsClient)
-	})
+	history, err := delivery.NewHistory(cfg.Delivery.HistorySize, cfg.Delivery.HistoryFile)
+	if err != nil {
+		log.Fatalf("Failed to load delivery history: %v", err)
+	}
 
-	http.HandleFunc("/alert", func(w http.ResponseWriter, r *http.Request) {
-		bodyBytes, err := io.ReadAll(r.Body)
-		if err != nil {
-			log.Errorf("Failed to read request body: %v", err)
-			http.Error(w, "Failed to read request body", http.StatusBadRequest)
-			return
-		}
-		defer r.Body.Close()
+	heartbeatMonitor, err := heartbeat.New(cfg, awsClient)
+	if err != nil {
+		log.Fatalf("Failed to initialize heartbeat monitor: %v", err)
+	}
 
-		if log.IsLevelEnabled(log.DebugLevel) {
-			log.Debugf("Received alert: %s", string(bodyBytes))
-		}
+	metaAlert, err := selfmon.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize meta alert: %v", err)
+	}
 
-		r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	alertHandler, err := alertmanager.NewHandler(cfg, metaAlert.Wrap(awsClient), silences, heartbeatMonitor, history)
+	if err != nil {
+		log.Fatalf("Failed to initialize alert handler: %v", err)
+	}
 
-		alertHandler.SNSHandler(w, r)
-	})
+	alertAuth := mustAuthenticator("alert", cfg.Auth.Alert)
+	statusAuth := mustAuthenticator("status", cfg.Auth.Status)
+	metricsAuth := mustAuthenticator("metrics", cfg.Auth.Metrics)
+	apiAuth := mustAuthenticator("api", cfg.Auth.API)
 
-	http.Handle("/metrics", promhttp.Handler())
+	signatureVerifier, err := auth.NewSignatureVerifier(cfg.Auth.Signatures)
+	if err != nil {
+		log.Fatalf("Failed to configure webhook signature verification: %v", err)
+	}
 
-	server := &http.Server{
-		Addr:              ":8080",
-		ReadTimeout:       time.Duration(cfg.Timeouts.Server.ReadTimeoutSeconds) * time.Second,
-		WriteTimeout:      time.Duration(cfg.Timeouts.Server.WriteTimeoutSeconds) * time.Second,
-		IdleTimeout:       time.Duration(cfg.Timeouts.Server.IdleTimeoutSeconds) * time.Second,
-		ReadHeaderTimeout: time.Duration(cfg.Timeouts.Server.ReadHeaderTimeoutSeconds) * time.Second,
+	mux := http.NewServeMux()
+	adminMux := mux
+	if cfg.Web.AdminListenAddress != "" {
+		adminMux = http.NewServeMux()
+	}
+
+	alertEndpoint := http.HandlerFunc(alertHandler.SNSHandler)
+	mux.Handle("/alert", tracing.Middleware("/alert", web.LimitRequestBody(cfg.Web.MaxRequestBodyBytes, alertAuth.Middleware(signatureVerifier.Middleware(alertEndpoint)))))
+
+	checker := health.NewChecker(cfg, awsClient, alertHandler)
+	adminMux.Handle("/-/healthy", statusAuth.Middleware(http.HandlerFunc(checker.LivenessHandler)))
+	adminMux.Handle("/-/ready", statusAuth.Middleware(http.HandlerFunc(checker.ReadinessHandler)))
+	adminMux.Handle("/status", statusAuth.Middleware(http.HandlerFunc(checker.ReadinessHandler)))
+
+	apiMux := http.NewServeMux()
+	silence.RegisterHandlers(apiMux, silences)
+	delivery.RegisterHandlers(apiMux, history)
+	adminMux.Handle("/api/", apiAuth.Middleware(apiMux))
+	adminMux.Handle("GET /deliveries", apiAuth.Middleware(delivery.PageHandler(history)))
+
+	adminMux.Handle("/metrics", metricsAuth.Middleware(promhttp.Handler()))
+
+	servers := []*http.Server{newServer(cfg, cfg.Web.ListenAddress, web.RequestID(mux))}
+	if cfg.Web.AdminListenAddress != "" {
+		servers = append(servers, newServer(cfg, cfg.Web.AdminListenAddress, web.RequestID(adminMux)))
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -75,12 +121,26 @@ func main() {
 		alertHandler.ProcessBatches(ctx)
 	}()
 
-	go func() {
-		log.Infof("Server started on %s", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+	go silences.Run(ctx, time.Duration(cfg.Silences.GCIntervalSeconds)*time.Second)
+	go history.Run(ctx, 30*time.Second)
+	go checker.Run(ctx)
+	go heartbeatMonitor.Run(ctx)
+	go metaAlert.Run(ctx)
+
+	if cfg.Web.TLS != nil {
+		tlsManager, err := web.NewTLSManager(*cfg.Web.TLS)
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
 		}
-	}()
+		for _, server := range servers {
+			server.TLSConfig = tlsManager.TLSConfig()
+		}
+		go tlsManager.Run(ctx, time.Duration(cfg.Web.TLS.ReloadIntervalSeconds)*time.Second)
+	}
+
+	for _, server := range servers {
+		go serve(server)
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -88,15 +148,63 @@ func main() {
 
 	log.Info("Shutting down server...")
 
-	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(cfg.Timeouts.ShutdownTimeoutSeconds)*time.Second)
 	defer cancelShutdown()
 
-	if err := server.Shutdown(ctxShutdown); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	for _, server := range servers {
+		if err := server.Shutdown(ctxShutdown); err != nil {
+			log.Errorf("Server forced to shutdown: %v", err)
+		}
 	}
 
 	cancel()
 	wg.Wait()
 
+	log.Info("Draining pending alerts...")
+	alertHandler.Drain(ctxShutdown)
+
+	if err := history.Save(); err != nil {
+		log.Errorf("Error saving delivery history: %v", err)
+	}
+
+	ctxTracing, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+	if err := shutdownTracing(ctxTracing); err != nil {
+		log.Errorf("Error flushing traces: %v", err)
+	}
+
 	log.Info("Server exiting")
 }
+
+func mustAuthenticator(endpoint string, authCfg config.EndpointAuthConfig) *auth.Authenticator {
+	authenticator, err := auth.New(endpoint, authCfg)
+	if err != nil {
+		log.Fatalf("Failed to configure authentication for %s endpoint: %v", endpoint, err)
+	}
+	return authenticator
+}
+
+func newServer(cfg config.Config, addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       time.Duration(cfg.Timeouts.Server.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout:      time.Duration(cfg.Timeouts.Server.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(cfg.Timeouts.Server.IdleTimeoutSeconds) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.Timeouts.Server.ReadHeaderTimeoutSeconds) * time.Second,
+	}
+}
+
+func serve(server *http.Server) {
+	log.Infof("Server started on %s (TLS: %t)", server.Addr, server.TLSConfig != nil)
+
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Server failed to start: %v", err)
+	}
+}
@@ -8,11 +8,82 @@ import (
 )
 
 type SNSTopicConfig struct {
-	Name       string   `yaml:"name"`
-	ARN        string   `yaml:"arn"`
-	StartTime  string   `yaml:"start_time"`
-	EndTime    string   `yaml:"end_time"`
-	DaysOfWeek []string `yaml:"days_of_week"`
+	Name                  string   `yaml:"name"`
+	ARN                   string   `yaml:"arn"`
+	StartTime             string   `yaml:"start_time"`
+	EndTime               string   `yaml:"end_time"`
+	DaysOfWeek            []string `yaml:"days_of_week"`
+	Protocol              string   `yaml:"protocol"`
+	MaxMessageSize        int      `yaml:"max_message_size"`
+	SplitMode             string   `yaml:"split_mode"`
+	GroupBy               []string `yaml:"group_by"`
+	GroupWaitSeconds      int      `yaml:"group_wait_seconds"`
+	GroupIntervalSeconds  int      `yaml:"group_interval_seconds"`
+	RepeatIntervalSeconds int      `yaml:"repeat_interval_seconds"`
+	DedupTTLSeconds       int      `yaml:"dedup_ttl_seconds"`
+}
+
+type FilterRule struct {
+	Name               string   `yaml:"name"`
+	Action             string   `yaml:"action"`
+	Matchers           []string `yaml:"matchers"`
+	AnnotationMatchers []string `yaml:"annotation_matchers"`
+}
+
+type InhibitRule struct {
+	SourceMatchers []string `yaml:"source_matchers"`
+	TargetMatchers []string `yaml:"target_matchers"`
+	Equal          []string `yaml:"equal"`
+}
+
+type SilencesConfig struct {
+	File              string `yaml:"file"`
+	GCIntervalSeconds int    `yaml:"gc_interval_seconds"`
+	RetentionSeconds  int    `yaml:"retention_seconds"`
+}
+
+type EndpointAuthConfig struct {
+	BearerTokenFiles []string          `yaml:"bearer_token_files"`
+	BasicAuthUsers   map[string]string `yaml:"basic_auth_users"`
+}
+
+type SignatureSource struct {
+	Name       string `yaml:"name"`
+	SecretFile string `yaml:"secret_file"`
+}
+
+type SignatureConfig struct {
+	SignatureHeader string            `yaml:"signature_header"`
+	TimestampHeader string            `yaml:"timestamp_header"`
+	SourceHeader    string            `yaml:"source_header"`
+	MaxAgeSeconds   int               `yaml:"max_age_seconds"`
+	Require         bool              `yaml:"require"`
+	Sources         []SignatureSource `yaml:"sources"`
+}
+
+type AuthConfig struct {
+	Alert      EndpointAuthConfig `yaml:"alert"`
+	Status     EndpointAuthConfig `yaml:"status"`
+	Metrics    EndpointAuthConfig `yaml:"metrics"`
+	API        EndpointAuthConfig `yaml:"api"`
+	Signatures SignatureConfig    `yaml:"signatures"`
+}
+
+type TLSConfig struct {
+	CertFile              string   `yaml:"cert_file"`
+	KeyFile               string   `yaml:"key_file"`
+	ClientCAFile          string   `yaml:"client_ca_file"`
+	ClientAllowedSubjects []string `yaml:"client_allowed_subjects"`
+	MinVersion            string   `yaml:"min_version"`
+	CipherSuites          []string `yaml:"cipher_suites"`
+	ReloadIntervalSeconds int      `yaml:"reload_interval_seconds"`
+}
+
+type WebConfig struct {
+	ListenAddress       string     `yaml:"listen_address"`
+	AdminListenAddress  string     `yaml:"admin_listen_address"`
+	MaxRequestBodyBytes int64      `yaml:"max_request_body_bytes"`
+	TLS                 *TLSConfig `yaml:"tls"`
 }
 
 type ServerTimeouts struct {
@@ -32,20 +103,99 @@ type AWSTimeouts struct {
 	APICallTimeoutSeconds        int `yaml:"api_call_timeout_seconds"`
 }
 
+type DeliveryConfig struct {
+	Workers     int    `yaml:"workers"`
+	QueueSize   int    `yaml:"queue_size"`
+	HistorySize int    `yaml:"history_size"`
+	HistoryFile string `yaml:"history_file"`
+}
+
+type QueueConfig struct {
+	Capacity          int    `yaml:"capacity"`
+	OverloadPolicy    string `yaml:"overload_policy"`
+	SpillFile         string `yaml:"spill_file"`
+	RetryAfterSeconds int    `yaml:"retry_after_seconds"`
+}
+
+type TracingConfig struct {
+	Endpoint    string            `yaml:"endpoint"`
+	Insecure    bool              `yaml:"insecure"`
+	Headers     map[string]string `yaml:"headers"`
+	ServiceName string            `yaml:"service_name"`
+	SampleRatio float64           `yaml:"sample_ratio"`
+}
+
+type HeartbeatConfig struct {
+	Matchers              []string `yaml:"matchers"`
+	TimeoutSeconds        int      `yaml:"timeout_seconds"`
+	Topic                 string   `yaml:"topic"`
+	Message               string   `yaml:"message"`
+	RepeatIntervalSeconds int      `yaml:"repeat_interval_seconds"`
+	SendResolved          bool     `yaml:"send_resolved"`
+	ResolvedMessage       string   `yaml:"resolved_message"`
+}
+
+type MetaAlertSNSConfig struct {
+	TopicARN string `yaml:"topic_arn"`
+	Region   string `yaml:"region"`
+}
+
+type MetaAlertConfig struct {
+	WindowSeconds      int                `yaml:"window_seconds"`
+	FailureRatio       float64            `yaml:"failure_ratio"`
+	MinAttempts        int                `yaml:"min_attempts"`
+	MinIntervalSeconds int                `yaml:"min_interval_seconds"`
+	SNS                MetaAlertSNSConfig `yaml:"sns"`
+	WebhookURL         string             `yaml:"webhook_url"`
+	SendResolved       bool               `yaml:"send_resolved"`
+}
+
+type HealthConfig struct {
+	CheckIntervalSeconds    int     `yaml:"check_interval_seconds"`
+	BatchLoopTimeoutSeconds int     `yaml:"batch_loop_timeout_seconds"`
+	MaxQueueUtilization     float64 `yaml:"max_queue_utilization"`
+	CheckPublishPermission  bool    `yaml:"check_publish_permission"`
+}
+
+type MetricsConfig struct {
+	AlertnameCardinalityLimit int `yaml:"alertname_cardinality_limit"`
+}
+
 type Timeouts struct {
-	Server ServerTimeouts `yaml:"server"`
-	AWS    AWSTimeouts    `yaml:"aws"`
+	Server                 ServerTimeouts `yaml:"server"`
+	AWS                    AWSTimeouts    `yaml:"aws"`
+	ShutdownTimeoutSeconds int            `yaml:"shutdown_timeout_seconds"`
 }
 
 type Config struct {
-	AWSRegion        string           `yaml:"aws_region"`
-	AWSAccessKey     string           `yaml:"aws_access_key"`
-	AWSSecretKey     string           `yaml:"aws_secret_key"`
-	Topics           []SNSTopicConfig `yaml:"sns_topics"`
-	AlertNames       []string         `yaml:"alertnames"`
-	BatchWaitSeconds int              `yaml:"batch_wait_seconds"`
-	Timeouts         Timeouts         `yaml:"timeouts"`
-	LogLevel         string           `yaml:"log_level"`
+	AWSRegion             string           `yaml:"aws_region"`
+	AWSAccessKey          string           `yaml:"aws_access_key"`
+	AWSSecretKey          string           `yaml:"aws_secret_key"`
+	Topics                []SNSTopicConfig `yaml:"sns_topics"`
+	AlertNames            []string         `yaml:"alertnames"`
+	Filters               []FilterRule     `yaml:"filters"`
+	InhibitRules          []InhibitRule    `yaml:"inhibit_rules"`
+	Silences              SilencesConfig   `yaml:"silences"`
+	Auth                  AuthConfig       `yaml:"auth"`
+	Web                   WebConfig        `yaml:"web"`
+	GroupBy               []string         `yaml:"group_by"`
+	BatchWaitSeconds      int              `yaml:"batch_wait_seconds"`
+	GroupWaitSeconds      int              `yaml:"group_wait_seconds"`
+	GroupIntervalSeconds  int              `yaml:"group_interval_seconds"`
+	RepeatIntervalSeconds int              `yaml:"repeat_interval_seconds"`
+	DedupTTLSeconds       int              `yaml:"dedup_ttl_seconds"`
+	ResolveTimeoutSeconds int              `yaml:"resolve_timeout_seconds"`
+	NotificationLogFile   string           `yaml:"notification_log_file"`
+	Queue                 QueueConfig      `yaml:"queue"`
+	Metrics               MetricsConfig    `yaml:"metrics"`
+	Health                HealthConfig     `yaml:"health"`
+	Heartbeat             HeartbeatConfig  `yaml:"heartbeat"`
+	MetaAlert             MetaAlertConfig  `yaml:"meta_alert"`
+	Tracing               TracingConfig    `yaml:"tracing"`
+	Delivery              DeliveryConfig   `yaml:"delivery"`
+	Timeouts              Timeouts         `yaml:"timeouts"`
+	LogLevel              string           `yaml:"log_level"`
+	LogFormat             string           `yaml:"log_format"`
 }
 
 var readFile = os.ReadFile
@@ -66,27 +216,126 @@ func LoadConfig(configFilePath string) Config {
 		log.Fatal("Missing required fields in config file")
 	}
 
-	if cfg.BatchWaitSeconds <= 0 {
-		log.Fatal("batch_wait_seconds must be a positive integer")
+	if cfg.Health.MaxQueueUtilization > 1 {
+		log.Fatalf("Invalid health.max_queue_utilization %v, expected a value between 0 and 1", cfg.Health.MaxQueueUtilization)
 	}
 
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		log.Fatalf("Invalid tracing.sample_ratio %v, expected a value between 0 and 1", cfg.Tracing.SampleRatio)
+	}
+
+	if cfg.MetaAlert.FailureRatio < 0 || cfg.MetaAlert.FailureRatio > 1 {
+		log.Fatalf("Invalid meta_alert.failure_ratio %v, expected a value between 0 and 1", cfg.MetaAlert.FailureRatio)
+	}
+
+	switch cfg.Queue.OverloadPolicy {
+	case "", "reject", "drop_oldest":
+	case "spill":
+		if cfg.Queue.SpillFile == "" {
+			log.Fatal("queue.spill_file is required for the 'spill' overload policy")
+		}
+	default:
+		log.Fatalf("Invalid queue.overload_policy '%s', expected 'reject', 'drop_oldest' or 'spill'", cfg.Queue.OverloadPolicy)
+	}
+
+	for i, rule := range cfg.Filters {
+		if rule.Action != "include" && rule.Action != "exclude" {
+			log.Fatalf("Invalid action '%s' in filter rule %d, expected 'include' or 'exclude'", rule.Action, i)
+		}
+	}
+
+	if cfg.DedupTTLSeconds < 0 {
+		log.Fatal("dedup_ttl_seconds must not be negative")
+	}
+
+	if cfg.BatchWaitSeconds < 0 || cfg.GroupWaitSeconds < 0 || cfg.GroupIntervalSeconds < 0 || cfg.RepeatIntervalSeconds < 0 || cfg.ResolveTimeoutSeconds < 0 {
+		log.Fatal("batch_wait_seconds, group_wait_seconds, group_interval_seconds, repeat_interval_seconds and resolve_timeout_seconds must not be negative")
+	}
+
+	for _, topic := range cfg.Topics {
+		switch topic.SplitMode {
+		case "", "split", "truncate":
+		default:
+			log.Fatalf("Invalid split_mode '%s' for topic %s, expected 'split' or 'truncate'", topic.SplitMode, topic.Name)
+		}
+		if topic.MaxMessageSize < 0 {
+			log.Fatalf("max_message_size for topic %s must not be negative", topic.Name)
+		}
+		if topic.GroupWaitSeconds < 0 || topic.GroupIntervalSeconds < 0 || topic.RepeatIntervalSeconds < 0 {
+			log.Fatalf("Group timers for topic %s must not be negative", topic.Name)
+		}
+		if topic.DedupTTLSeconds < 0 {
+			log.Fatalf("dedup_ttl_seconds for topic %s must not be negative", topic.Name)
+		}
+	}
+
+	setLogFormat(cfg.LogFormat)
+
 	setLogLevel(cfg.LogLevel)
 
 	setDefaultTimeouts(&cfg)
 
+	setDefaultQueue(&cfg)
+
+	setDefaultMetrics(&cfg)
+
+	setDefaultHealth(&cfg)
+
+	setDefaultHeartbeat(&cfg)
+
+	setDefaultMetaAlert(&cfg)
+
+	setDefaultTracing(&cfg)
+
+	setDefaultDelivery(&cfg)
+
+	setDefaultGroupBy(&cfg)
+
+	setDefaultGroupTimers(&cfg)
+
+	setDefaultSilences(&cfg)
+
+	setDefaultWeb(&cfg)
+
+	setDefaultSignatures(&cfg)
+
 	return cfg
 }
 
 func setLogLevel(logLevel string) {
-	switch logLevel {
-	case "debug":
-		log.SetLevel(log.DebugLevel)
-		log.Debug("Log level set to DEBUG")
-	case "info":
+	if logLevel == "" {
 		log.SetLevel(log.InfoLevel)
-	default:
+		return
+	}
+
+	level, err := log.ParseLevel(logLevel)
+	if err != nil {
 		log.SetLevel(log.InfoLevel)
 		log.Warnf("Invalid log level '%s', defaulting to INFO", logLevel)
+		return
+	}
+	log.SetLevel(level)
+	log.Debugf("Log level set to %s", level)
+}
+
+func setLogFormat(logFormat string) {
+	switch logFormat {
+	case "", "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	case "logfmt":
+		log.SetFormatter(&log.TextFormatter{DisableColors: true, FullTimestamp: true})
+	case "text":
+		// Meant for reading rather than parsing: values are not quoted and
+		// levels are padded to line up, colored on a terminal.
+		log.SetFormatter(&log.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: "2006-01-02 15:04:05",
+			DisableQuote:    true,
+			PadLevelText:    true,
+		})
+	default:
+		log.SetFormatter(&log.JSONFormatter{})
+		log.Warnf("Invalid log format '%s', defaulting to json", logFormat)
 	}
 }
 
@@ -104,6 +353,10 @@ func setDefaultTimeouts(cfg *Config) {
 		cfg.Timeouts.Server.ReadHeaderTimeoutSeconds = 5
 	}
 
+	if cfg.Timeouts.ShutdownTimeoutSeconds == 0 {
+		cfg.Timeouts.ShutdownTimeoutSeconds = 30
+	}
+
 	if cfg.Timeouts.AWS.DialTimeoutSeconds == 0 {
 		cfg.Timeouts.AWS.DialTimeoutSeconds = 5
 	}
@@ -126,3 +379,167 @@ func setDefaultTimeouts(cfg *Config) {
 		cfg.Timeouts.AWS.APICallTimeoutSeconds = 10
 	}
 }
+
+func setDefaultQueue(cfg *Config) {
+	if cfg.Queue.Capacity <= 0 {
+		cfg.Queue.Capacity = 100
+	}
+	if cfg.Queue.OverloadPolicy == "" {
+		cfg.Queue.OverloadPolicy = "reject"
+	}
+	if cfg.Queue.RetryAfterSeconds <= 0 {
+		cfg.Queue.RetryAfterSeconds = 5
+	}
+}
+
+func setDefaultMetrics(cfg *Config) {
+	if cfg.Metrics.AlertnameCardinalityLimit <= 0 {
+		cfg.Metrics.AlertnameCardinalityLimit = 100
+	}
+}
+
+func setDefaultHealth(cfg *Config) {
+	if cfg.Health.CheckIntervalSeconds <= 0 {
+		cfg.Health.CheckIntervalSeconds = 30
+	}
+	if cfg.Health.BatchLoopTimeoutSeconds <= 0 {
+		cfg.Health.BatchLoopTimeoutSeconds = 30
+	}
+	if cfg.Health.MaxQueueUtilization <= 0 {
+		cfg.Health.MaxQueueUtilization = 0.9
+	}
+}
+
+func setDefaultHeartbeat(cfg *Config) {
+	if cfg.Heartbeat.TimeoutSeconds <= 0 {
+		cfg.Heartbeat.TimeoutSeconds = 600
+	}
+	if cfg.Heartbeat.Message == "" {
+		cfg.Heartbeat.Message = "Alerting pipeline is down: the heartbeat alert stopped arriving."
+	}
+	if cfg.Heartbeat.ResolvedMessage == "" {
+		cfg.Heartbeat.ResolvedMessage = "Alerting pipeline recovered: the heartbeat alert is arriving again."
+	}
+}
+
+func setDefaultMetaAlert(cfg *Config) {
+	if cfg.MetaAlert.WindowSeconds <= 0 {
+		cfg.MetaAlert.WindowSeconds = 300
+	}
+	if cfg.MetaAlert.FailureRatio <= 0 {
+		cfg.MetaAlert.FailureRatio = 0.5
+	}
+	if cfg.MetaAlert.MinAttempts <= 0 {
+		cfg.MetaAlert.MinAttempts = 5
+	}
+	if cfg.MetaAlert.MinIntervalSeconds <= 0 {
+		cfg.MetaAlert.MinIntervalSeconds = 900
+	}
+	if cfg.MetaAlert.SNS.Region == "" {
+		cfg.MetaAlert.SNS.Region = cfg.AWSRegion
+	}
+}
+
+func setDefaultTracing(cfg *Config) {
+	if cfg.Tracing.ServiceName == "" {
+		cfg.Tracing.ServiceName = "alertmanager-sns-forwarder"
+	}
+	if cfg.Tracing.SampleRatio == 0 {
+		cfg.Tracing.SampleRatio = 1
+	}
+}
+
+func setDefaultDelivery(cfg *Config) {
+	if cfg.Delivery.Workers <= 0 {
+		cfg.Delivery.Workers = 4
+	}
+	if cfg.Delivery.QueueSize <= 0 {
+		cfg.Delivery.QueueSize = 100
+	}
+	if cfg.Delivery.HistorySize <= 0 {
+		cfg.Delivery.HistorySize = 1000
+	}
+}
+
+func setDefaultGroupBy(cfg *Config) {
+	if cfg.GroupBy == nil {
+		cfg.GroupBy = []string{"alertname"}
+	}
+	for i := range cfg.Topics {
+		if cfg.Topics[i].GroupBy == nil {
+			cfg.Topics[i].GroupBy = cfg.GroupBy
+		}
+	}
+}
+
+func setDefaultGroupTimers(cfg *Config) {
+	if cfg.GroupWaitSeconds == 0 {
+		// batch_wait_seconds is the predecessor of group_wait_seconds.
+		cfg.GroupWaitSeconds = cfg.BatchWaitSeconds
+	}
+	if cfg.GroupWaitSeconds == 0 {
+		cfg.GroupWaitSeconds = 30
+	}
+	if cfg.GroupIntervalSeconds == 0 {
+		cfg.GroupIntervalSeconds = 300
+	}
+	if cfg.RepeatIntervalSeconds == 0 {
+		cfg.RepeatIntervalSeconds = 4 * 60 * 60
+	}
+	if cfg.ResolveTimeoutSeconds == 0 {
+		cfg.ResolveTimeoutSeconds = 12 * 60 * 60
+	}
+
+	for i := range cfg.Topics {
+		topic := &cfg.Topics[i]
+		if topic.GroupWaitSeconds == 0 {
+			topic.GroupWaitSeconds = cfg.GroupWaitSeconds
+		}
+		if topic.GroupIntervalSeconds == 0 {
+			topic.GroupIntervalSeconds = cfg.GroupIntervalSeconds
+		}
+		if topic.RepeatIntervalSeconds == 0 {
+			topic.RepeatIntervalSeconds = cfg.RepeatIntervalSeconds
+		}
+		if topic.DedupTTLSeconds == 0 {
+			topic.DedupTTLSeconds = cfg.DedupTTLSeconds
+		}
+	}
+}
+
+func setDefaultSilences(cfg *Config) {
+	if cfg.Silences.GCIntervalSeconds <= 0 {
+		cfg.Silences.GCIntervalSeconds = 60
+	}
+	if cfg.Silences.RetentionSeconds <= 0 {
+		cfg.Silences.RetentionSeconds = 24 * 60 * 60
+	}
+}
+
+func setDefaultWeb(cfg *Config) {
+	if cfg.Web.ListenAddress == "" {
+		cfg.Web.ListenAddress = ":8080"
+	}
+	if cfg.Web.MaxRequestBodyBytes <= 0 {
+		cfg.Web.MaxRequestBodyBytes = 4 << 20
+	}
+	if cfg.Web.TLS != nil && cfg.Web.TLS.ReloadIntervalSeconds <= 0 {
+		cfg.Web.TLS.ReloadIntervalSeconds = 30
+	}
+}
+
+func setDefaultSignatures(cfg *Config) {
+	signatures := &cfg.Auth.Signatures
+	if signatures.SignatureHeader == "" {
+		signatures.SignatureHeader = "X-Webhook-Signature"
+	}
+	if signatures.TimestampHeader == "" {
+		signatures.TimestampHeader = "X-Webhook-Timestamp"
+	}
+	if signatures.SourceHeader == "" {
+		signatures.SourceHeader = "X-Webhook-Source"
+	}
+	if signatures.MaxAgeSeconds <= 0 {
+		signatures.MaxAgeSeconds = 300
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestSetLogFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"", `"msg":"Alert sent"`},
+		{"json", `"msg":"Alert sent"`},
+		{"logfmt", `msg="Alert sent" topic="alerts topic"`},
+		{"text", `msg=Alert sent topic=alerts topic`},
+		{"xml", `"msg":"Alert sent"`},
+	}
+
+	defer log.SetOutput(log.StandardLogger().Out)
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setLogFormat(tt.format)
+
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			log.WithField("topic", "alerts topic").Info("Alert sent")
+
+			if got := buf.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("output = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  log.Level
+	}{
+		{"", log.InfoLevel},
+		{"debug", log.DebugLevel},
+		{"info", log.InfoLevel},
+		{"warn", log.WarnLevel},
+		{"warning", log.WarnLevel},
+		{"error", log.ErrorLevel},
+		{"WARN", log.WarnLevel},
+		{"verbose", log.InfoLevel},
+	}
+
+	defer log.SetLevel(log.GetLevel())
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			setLogLevel(tt.level)
+			if got := log.GetLevel(); got != tt.want {
+				t.Errorf("level = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package alertmanager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/aws"
+	"github.com/maks3201/sns-alert-service/internal/delivery"
+	"github.com/maks3201/sns-alert-service/internal/nflog"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// deliveryJob is a single message to be published to a single topic.
+type deliveryJob struct {
+	topic   config.SNSTopicConfig
+	group   groupID
+	message string
+	alerts  []Alert
+
+	// spanContext is the span of the batch flush that produced the job.
+	spanContext trace.SpanContext
+}
+
+// dispatcher delivers messages to SNS topics independently of each other.
+// Every topic has its own FIFO queue drained by a dedicated goroutine, so
+// ordering is preserved within a topic, while the number of concurrent
+// Publish calls across all topics is bounded by the worker count.
+type dispatcher struct {
+	awsClient  aws.SNSClient
+	nflog      *nflog.Log
+	history    *delivery.History
+	spill      *spillFile
+	apiTimeout time.Duration
+	queues     map[string]chan deliveryJob
+	sem        chan struct{}
+	failed     chan deliveryJob
+	wg         sync.WaitGroup
+
+	// ctx bounds all Publish calls and is cancelled when the shutdown
+	// deadline passes. drainCtx is set once draining has started.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	drainCtx context.Context
+}
+
+func newDispatcher(cfg config.Config, awsClient aws.SNSClient, notificationLog *nflog.Log, history *delivery.History, spill *spillFile) *dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &dispatcher{
+		awsClient:  awsClient,
+		nflog:      notificationLog,
+		history:    history,
+		spill:      spill,
+		apiTimeout: time.Duration(cfg.Timeouts.AWS.APICallTimeoutSeconds) * time.Second,
+		queues:     make(map[string]chan deliveryJob),
+		sem:        make(chan struct{}, cfg.Delivery.Workers),
+		failed:     make(chan deliveryJob, cfg.Delivery.QueueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	for _, topic := range cfg.Topics {
+		if _, ok := d.queues[topic.ARN]; ok {
+			continue
+		}
+		d.queues[topic.ARN] = make(chan deliveryJob, cfg.Delivery.QueueSize)
+	}
+	return d
+}
+
+func (d *dispatcher) start() {
+	for arn, queue := range d.queues {
+		d.wg.Add(1)
+		go d.worker(arn, queue)
+	}
+}
+
+// beginDrain switches enqueue to blocking mode, so that the final flush
+// waits for room in the topic queues until ctx is done instead of dropping
+// messages.
+func (d *dispatcher) beginDrain(ctx context.Context) {
+	d.drainCtx = ctx
+}
+
+// stop closes all topic queues and waits until the jobs already queued
+// have been delivered. Once ctx is done, in-flight Publish calls are
+// cancelled and the remaining jobs are reported as undelivered.
+func (d *dispatcher) stop(ctx context.Context) {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn("Shutdown deadline exceeded, cancelling pending deliveries")
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
+
+// enqueue schedules a job for delivery without blocking the caller. It
+// returns false if the topic queue is full. While draining, it waits for
+// room in the queue until the shutdown deadline instead.
+func (d *dispatcher) enqueue(job deliveryJob) bool {
+	queue, ok := d.queues[job.topic.ARN]
+	if !ok {
+		log.Errorf("No delivery queue for topic %s", job.topic.Name)
+		return false
+	}
+
+	var wait <-chan struct{}
+	if d.drainCtx != nil {
+		wait = d.drainCtx.Done()
+	}
+
+	select {
+	case queue <- job:
+		TopicQueueDepth.WithLabelValues(job.topic.Name).Set(float64(len(queue)))
+		return true
+	default:
+		if wait == nil {
+			return false
+		}
+	}
+
+	select {
+	case queue <- job:
+		TopicQueueDepth.WithLabelValues(job.topic.Name).Set(float64(len(queue)))
+		return true
+	case <-wait:
+		return false
+	}
+}
+
+// retry hands a job whose delivery failed back to the batching loop, so
+// that its alerts are sent again with the next flush of their group instead
+// of after repeat_interval. It does not block; if the batching loop falls
+// behind, the job is not retried.
+func (d *dispatcher) retry(job deliveryJob) {
+	select {
+	case d.failed <- job:
+	default:
+		log.Warnf("Retry queue is full, not retrying batch of %d alerts to SNS topic %s", len(job.alerts), job.topic.Name)
+	}
+}
+
+// undelivered reports the alerts of a job that could not be delivered
+// before shutdown and spills them to disk, if configured, so that they are
+// delivered after the next start.
+func (d *dispatcher) undelivered(job deliveryJob, reason string) {
+	names := make([]string, 0, len(job.alerts))
+	for _, alert := range job.alerts {
+		names = append(names, alert.Labels["alertname"])
+	}
+	log.WithField("request_ids", requestIDs(job.alerts)).Errorf("Undelivered message to SNS topic %s (%s): %d alerts %v", job.topic.Name, reason, len(job.alerts), names)
+	countFailed(job.topic.Name, job.alerts, failReasonShutdown)
+
+	if d.spill == nil {
+		return
+	}
+	if err := d.spill.append(job.topic.ARN, job.alerts); err != nil {
+		log.Errorf("Error spilling undelivered alerts: %v", err)
+		return
+	}
+	log.Infof("Spilled %d undelivered alerts to %s", len(job.alerts), d.spill.path)
+}
+
+func (d *dispatcher) worker(arn string, queue chan deliveryJob) {
+	defer d.wg.Done()
+
+	for job := range queue {
+		TopicQueueDepth.WithLabelValues(job.topic.Name).Set(float64(len(queue)))
+
+		if d.ctx.Err() != nil {
+			d.undelivered(job, "shutdown deadline exceeded")
+			continue
+		}
+
+		d.sem <- struct{}{}
+		d.deliver(job)
+		<-d.sem
+	}
+	log.Debugf("Delivery worker for %s stopped", arn)
+}
+
+func (d *dispatcher) deliver(job deliveryJob) {
+	publishCtx, cancel := context.WithTimeout(trace.ContextWithSpanContext(d.ctx, job.spanContext), d.apiTimeout)
+	defer cancel()
+
+	startSend := time.Now()
+	result, err := d.awsClient.PublishToSNS(publishCtx, job.topic.ARN, job.message)
+	duration := time.Since(startSend).Seconds()
+	SNSSendDuration.WithLabelValues(job.topic.Name).Observe(duration)
+
+	record := delivery.Record{
+		Time:           time.Now(),
+		Topic:          job.topic.Name,
+		TopicARN:       job.topic.ARN,
+		Status:         delivery.StatusSent,
+		MessageID:      result.MessageID,
+		SequenceNumber: result.SequenceNumber,
+		Alerts:         alertRefs(job.alerts),
+		Message:        job.message,
+	}
+	if err != nil {
+		record.Status = delivery.StatusFailed
+		record.Error = err.Error()
+	}
+	d.history.Add(record)
+
+	if err != nil {
+		SNSPublishErrors.WithLabelValues(job.topic.Name, errorCode(err)).Inc()
+		if d.ctx.Err() != nil {
+			d.undelivered(job, err.Error())
+			return
+		}
+		log.WithFields(log.Fields{
+			"topic":        job.topic.Name,
+			"fingerprints": fingerprints(job.alerts),
+			"request_ids":  requestIDs(job.alerts),
+		}).Errorf("Error sending batch message to SNS topic %s: %v", job.topic.Name, err)
+		countFailed(job.topic.Name, job.alerts, failReasonPublish)
+		d.retry(job)
+		return
+	}
+
+	publishedAt := time.Now()
+	for _, alert := range job.alerts {
+		AlertsSent.WithLabelValues(job.topic.Name, alertnameLabel(alert), alert.Status).Inc()
+		if !alert.receivedAt.IsZero() {
+			AlertDeliveryLatency.WithLabelValues(job.topic.Name).Observe(publishedAt.Sub(alert.receivedAt).Seconds())
+		}
+		if startsAt, err := time.Parse(time.RFC3339, alert.StartsAt); err == nil {
+			AlertAgeAtPublish.WithLabelValues(job.topic.Name).Observe(publishedAt.Sub(startsAt).Seconds())
+		}
+	}
+	BatchesSent.WithLabelValues(job.topic.Name).Inc()
+	BatchSize.WithLabelValues(job.topic.Name).Observe(float64(len(job.alerts)))
+
+	log.WithFields(log.Fields{
+		"topic":           job.topic.Name,
+		"topic_arn":       job.topic.ARN,
+		"message_id":      result.MessageID,
+		"sequence_number": result.SequenceNumber,
+		"fingerprints":    fingerprints(job.alerts),
+		"request_ids":     requestIDs(job.alerts),
+	}).Infof("Batch alert sent to SNS topic: %s", job.topic.ARN)
+
+	if job.topic.DedupTTLSeconds > 0 {
+		keys := make([]string, 0, len(job.alerts))
+		for _, alert := range job.alerts {
+			keys = append(keys, notificationKey(job.topic.ARN, alert))
+		}
+		expiresAt := time.Now().Add(time.Duration(job.topic.DedupTTLSeconds) * time.Second)
+		if err := d.nflog.Record(keys, expiresAt); err != nil {
+			log.Errorf("Error updating notification log: %v", err)
+		}
+	}
+}
+
+func fingerprints(alerts []Alert) []string {
+	result := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		result = append(result, labelsFingerprint(alert.Labels))
+	}
+	return result
+}
+
+// requestIDs returns the distinct IDs of the requests that delivered the
+// alerts.
+func requestIDs(alerts []Alert) []string {
+	seen := make(map[string]bool, len(alerts))
+	result := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.requestID == "" || seen[alert.requestID] {
+			continue
+		}
+		seen[alert.requestID] = true
+		result = append(result, alert.requestID)
+	}
+	return result
+}
+
+func alertRefs(alerts []Alert) []delivery.AlertRef {
+	refs := make([]delivery.AlertRef, 0, len(alerts))
+	for _, alert := range alerts {
+		refs = append(refs, delivery.AlertRef{
+			Alertname:   alert.Labels["alertname"],
+			Fingerprint: labelsFingerprint(alert.Labels),
+			Status:      alert.Status,
+			StartsAt:    alert.StartsAt,
+			Labels:      alert.Labels,
+		})
+	}
+	return refs
+}
@@ -0,0 +1,166 @@
+package alertmanager
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/aws"
+	"github.com/maks3201/sns-alert-service/internal/delivery"
+	"github.com/maks3201/sns-alert-service/internal/nflog"
+)
+
+// blockingSNSClient holds every publish until it is released and records
+// the order of publishes per topic and the highest number of concurrent
+// publishes.
+type blockingSNSClient struct {
+	release chan struct{}
+	started chan struct{}
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	published   map[string][]string
+}
+
+func newBlockingSNSClient() *blockingSNSClient {
+	return &blockingSNSClient{
+		release:   make(chan struct{}),
+		started:   make(chan struct{}, 100),
+		published: make(map[string][]string),
+	}
+}
+
+func (c *blockingSNSClient) PublishToSNS(ctx context.Context, topicArn string, message string) (aws.PublishResult, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.mu.Unlock()
+	c.started <- struct{}{}
+
+	<-c.release
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+	c.published[topicArn] = append(c.published[topicArn], message)
+	return aws.PublishResult{MessageID: message}, nil
+}
+
+func (c *blockingSNSClient) CheckSNSConnection(ctx context.Context) error {
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, topics, workers int, client aws.SNSClient) (*dispatcher, []config.SNSTopicConfig) {
+	t.Helper()
+	cfg := config.Config{Delivery: config.DeliveryConfig{Workers: workers, QueueSize: 100}}
+	cfg.Timeouts.AWS.APICallTimeoutSeconds = 5
+	for i := 0; i < topics; i++ {
+		cfg.Topics = append(cfg.Topics, config.SNSTopicConfig{
+			Name: fmt.Sprintf("topic-%d", i),
+			ARN:  fmt.Sprintf("arn:aws:sns:eu-central-1:123456789012:topic-%d", i),
+		})
+	}
+
+	notificationLog, err := nflog.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := delivery.NewHistory(100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newDispatcher(cfg, client, notificationLog, history, nil), cfg.Topics
+}
+
+func TestDispatcherLimitsConcurrentPublishes(t *testing.T) {
+	tests := []struct {
+		topics  int
+		workers int
+	}{
+		{topics: 4, workers: 1},
+		{topics: 4, workers: 2},
+		{topics: 4, workers: 4},
+		{topics: 2, workers: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d topics %d workers", tt.topics, tt.workers), func(t *testing.T) {
+			client := newBlockingSNSClient()
+			d, topics := newTestDispatcher(t, tt.topics, tt.workers, client)
+			d.start()
+
+			for _, topic := range topics {
+				for i := 0; i < 2; i++ {
+					if !d.enqueue(deliveryJob{topic: topic, message: "message"}) {
+						t.Fatal("enqueue failed")
+					}
+				}
+			}
+
+			want := min(tt.topics, tt.workers)
+			for i := 0; i < want; i++ {
+				<-client.started
+			}
+			select {
+			case <-client.started:
+				t.Fatalf("more than %d publishes started at once", want)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			close(client.release)
+			d.stop(context.Background())
+
+			if client.maxInFlight != want {
+				t.Errorf("max concurrent publishes = %d, want %d", client.maxInFlight, want)
+			}
+		})
+	}
+}
+
+func TestDispatcherKeepsOrderWithinTopic(t *testing.T) {
+	client := newBlockingSNSClient()
+	close(client.release)
+	d, topics := newTestDispatcher(t, 3, 3, client)
+	d.start()
+
+	want := make(map[string][]string)
+	for i := 0; i < 20; i++ {
+		for _, topic := range topics {
+			message := fmt.Sprintf("%s-%d", topic.Name, i)
+			want[topic.ARN] = append(want[topic.ARN], message)
+			if !d.enqueue(deliveryJob{topic: topic, message: message}) {
+				t.Fatal("enqueue failed")
+			}
+		}
+	}
+	d.stop(context.Background())
+
+	if !reflect.DeepEqual(client.published, want) {
+		t.Errorf("published = %v, want %v", client.published, want)
+	}
+}
+
+func TestDispatcherSlowTopicDoesNotBlockOthers(t *testing.T) {
+	client := newBlockingSNSClient()
+	d, topics := newTestDispatcher(t, 2, 2, client)
+	d.start()
+
+	// The first topic's publish blocks until released, the second one's
+	// must start regardless.
+	d.enqueue(deliveryJob{topic: topics[0], message: "slow"})
+	<-client.started
+	d.enqueue(deliveryJob{topic: topics[1], message: "fast"})
+	select {
+	case <-client.started:
+	case <-time.After(time.Second):
+		t.Fatal("publish to the second topic waited for the first topic")
+	}
+
+	close(client.release)
+	d.stop(context.Background())
+}
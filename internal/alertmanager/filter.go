@@ -0,0 +1,96 @@
+package alertmanager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/matchers"
+)
+
+const (
+	filterActionInclude = "include"
+	filterActionExclude = "exclude"
+
+	// defaultFilterRule is the rule name reported when no configured rule
+	// matched an alert.
+	defaultFilterRule = "default"
+)
+
+// filterRule includes or excludes the alerts whose labels and annotations
+// match all of its matchers.
+type filterRule struct {
+	name               string
+	action             string
+	matchers           matchers.Matchers
+	annotationMatchers matchers.Matchers
+}
+
+func (r filterRule) matches(alert Alert) bool {
+	return r.matchers.Matches(alert.Labels) && r.annotationMatchers.Matches(alert.Annotations)
+}
+
+// compileFilters builds the filter rules from the configuration. The legacy
+// alertnames list is converted into an include rule on the alertname label.
+func compileFilters(cfg config.Config) ([]filterRule, error) {
+	rules := make([]filterRule, 0, len(cfg.Filters)+1)
+
+	for i, ruleCfg := range cfg.Filters {
+		name := ruleCfg.Name
+		if name == "" {
+			name = fmt.Sprintf("rule_%d", i)
+		}
+
+		labelMatchers, err := matchers.ParseAll(ruleCfg.Matchers)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %v", name, err)
+		}
+		annotationMatchers, err := matchers.ParseAll(ruleCfg.AnnotationMatchers)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %v", name, err)
+		}
+
+		rules = append(rules, filterRule{
+			name:               name,
+			action:             ruleCfg.Action,
+			matchers:           labelMatchers,
+			annotationMatchers: annotationMatchers,
+		})
+	}
+
+	if len(cfg.AlertNames) > 0 {
+		quoted := make([]string, 0, len(cfg.AlertNames))
+		for _, alertname := range cfg.AlertNames {
+			quoted = append(quoted, regexp.QuoteMeta(alertname))
+		}
+		m, err := matchers.New(matchers.MatchRegexp, "alertname", strings.Join(quoted, "|"))
+		if err != nil {
+			return nil, fmt.Errorf("alertnames: %v", err)
+		}
+		rules = append(rules, filterRule{
+			name:     "alertnames",
+			action:   filterActionInclude,
+			matchers: matchers.Matchers{m},
+		})
+	}
+
+	return rules, nil
+}
+
+// evaluateFilters applies the rules in order; the first matching rule
+// decides. If no rule matches, the alert is dropped when any include rule
+// is configured and kept otherwise. It returns whether the alert is allowed
+// and the name of the deciding rule.
+func evaluateFilters(rules []filterRule, alert Alert) (bool, string) {
+	hasInclude := false
+	for _, rule := range rules {
+		if rule.matches(alert) {
+			return rule.action == filterActionInclude, rule.name
+		}
+		if rule.action == filterActionInclude {
+			hasInclude = true
+		}
+	}
+	return !hasInclude, defaultFilterRule
+}
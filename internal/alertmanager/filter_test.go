@@ -0,0 +1,95 @@
+package alertmanager
+
+import (
+	"testing"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+func TestEvaluateFilters(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		alert       Alert
+		wantAllowed bool
+		wantRule    string
+	}{
+		{
+			name:        "no rules",
+			alert:       Alert{Labels: map[string]string{"alertname": "HighLoad"}},
+			wantAllowed: true,
+			wantRule:    defaultFilterRule,
+		},
+		{
+			name:        "legacy alertnames list",
+			cfg:         config.Config{AlertNames: []string{"HighLoad", "Disk.Full"}},
+			alert:       Alert{Labels: map[string]string{"alertname": "DiskXFull"}},
+			wantAllowed: false,
+			wantRule:    defaultFilterRule,
+		},
+		{
+			name:        "legacy alertnames match",
+			cfg:         config.Config{AlertNames: []string{"HighLoad", "Disk.Full"}},
+			alert:       Alert{Labels: map[string]string{"alertname": "Disk.Full"}},
+			wantAllowed: true,
+			wantRule:    "alertnames",
+		},
+		{
+			name: "first matching rule decides",
+			cfg: config.Config{Filters: []config.FilterRule{
+				{Name: "drop-dev", Action: filterActionExclude, Matchers: []string{`env="dev"`}},
+				{Name: "critical", Action: filterActionInclude, Matchers: []string{`severity="critical"`}},
+			}},
+			alert:       Alert{Labels: map[string]string{"env": "dev", "severity": "critical"}},
+			wantAllowed: false,
+			wantRule:    "drop-dev",
+		},
+		{
+			name: "unmatched alert with include rules is dropped",
+			cfg: config.Config{Filters: []config.FilterRule{
+				{Name: "critical", Action: filterActionInclude, Matchers: []string{`severity="critical"`}},
+			}},
+			alert:       Alert{Labels: map[string]string{"severity": "warning"}},
+			wantAllowed: false,
+			wantRule:    defaultFilterRule,
+		},
+		{
+			name: "unmatched alert with only exclude rules is kept",
+			cfg: config.Config{Filters: []config.FilterRule{
+				{Action: filterActionExclude, Matchers: []string{`env="dev"`}},
+			}},
+			alert:       Alert{Labels: map[string]string{"env": "prod"}},
+			wantAllowed: true,
+			wantRule:    defaultFilterRule,
+		},
+		{
+			name: "annotation matchers",
+			cfg: config.Config{Filters: []config.FilterRule{
+				{Action: filterActionInclude, AnnotationMatchers: []string{`runbook=~"https://.*"`}},
+			}},
+			alert:       Alert{Labels: map[string]string{"alertname": "HighLoad"}, Annotations: map[string]string{"runbook": "https://runbooks/high-load"}},
+			wantAllowed: true,
+			wantRule:    "rule_0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := compileFilters(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			allowed, rule := evaluateFilters(rules, tt.alert)
+			if allowed != tt.wantAllowed || rule != tt.wantRule {
+				t.Errorf("evaluateFilters() = (%v, %q), want (%v, %q)", allowed, rule, tt.wantAllowed, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestCompileFiltersInvalidMatcher(t *testing.T) {
+	cfg := config.Config{Filters: []config.FilterRule{{Name: "broken", Matchers: []string{`severity=~"("`}}}}
+	if _, err := compileFilters(cfg); err == nil {
+		t.Error("compileFilters() accepted an invalid regular expression")
+	}
+}
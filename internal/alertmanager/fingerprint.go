@@ -0,0 +1,34 @@
+package alertmanager
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// labelsFingerprint returns a stable hash identifying an alert by its label set.
+func labelsFingerprint(labels map[string]string) string {
+	hash := fnv.New64a()
+	for _, name := range sortedLabelNames(labels) {
+		hash.Write([]byte(name))
+		hash.Write([]byte{0xff})
+		hash.Write([]byte(labels[name]))
+		hash.Write([]byte{0xff})
+	}
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// notificationFingerprint identifies a single notification about an alert:
+// the same alert with the same status and start time is a duplicate.
+func notificationFingerprint(alert Alert) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(labelsFingerprint(alert.Labels)))
+	hash.Write([]byte{0xff})
+	hash.Write([]byte(alert.Status))
+	hash.Write([]byte{0xff})
+	hash.Write([]byte(alert.StartsAt))
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+func notificationKey(topicArn string, alert Alert) string {
+	return topicArn + "/" + notificationFingerprint(alert)
+}
@@ -0,0 +1,171 @@
+package alertmanager
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+const (
+	// snsMaxMessageBytes is the maximum size of an SNS message payload.
+	snsMaxMessageBytes = 256 * 1024
+	// smsMaxMessageChars is the maximum length of a message delivered over SMS.
+	smsMaxMessageChars = 1600
+
+	splitModeSplit    = "split"
+	splitModeTruncate = "truncate"
+)
+
+// renderedMessage is a message body together with the alerts it covers.
+type renderedMessage struct {
+	body   string
+	alerts []Alert
+}
+
+// messageLimit is the maximum message size for a topic, measured either in
+// bytes or in characters depending on the delivery protocol.
+type messageLimit struct {
+	size  int
+	runes bool
+}
+
+func (l messageLimit) measure(s string) int {
+	if l.runes {
+		return utf8.RuneCountInString(s)
+	}
+	return len(s)
+}
+
+func topicMessageLimit(topic config.SNSTopicConfig) messageLimit {
+	limit := messageLimit{size: snsMaxMessageBytes}
+	if strings.EqualFold(topic.Protocol, "sms") {
+		limit = messageLimit{size: smsMaxMessageChars, runes: true}
+	}
+	if topic.MaxMessageSize > 0 && topic.MaxMessageSize < limit.size {
+		limit.size = topic.MaxMessageSize
+	}
+	return limit
+}
+
+type messageEntry struct {
+	line  string
+	alert Alert
+}
+
+// renderMessages formats a group of alerts for a topic. If the result does
+// not fit into the topic's message limit, it is either split into several
+// numbered messages or truncated with a footer, depending on the topic's
+// split mode.
+func renderMessages(header string, alerts []Alert, topic config.SNSTopicConfig) []renderedMessage {
+	limit := topicMessageLimit(topic)
+
+	entries := make([]messageEntry, 0, len(alerts))
+	var full strings.Builder
+	full.WriteString(header + "\n")
+	for _, alert := range alerts {
+		entry := messageEntry{alert: alert}
+		if summary := alert.Annotations["summary"]; summary != "" {
+			entry.line = fmt.Sprintf("• %s\n", summary)
+			full.WriteString(entry.line)
+		}
+		entries = append(entries, entry)
+	}
+
+	if limit.measure(full.String()) <= limit.size {
+		return []renderedMessage{{body: full.String(), alerts: alerts}}
+	}
+
+	if topic.SplitMode == splitModeTruncate {
+		return []renderedMessage{truncateMessage(header, entries, limit)}
+	}
+	return splitMessage(header, entries, limit)
+}
+
+func splitMessage(header string, entries []messageEntry, limit messageLimit) []renderedMessage {
+	// Reserve room for the longest possible part counter.
+	maxHeader := fmt.Sprintf("%s (part %d/%d)\n", header, len(entries), len(entries))
+	available := limit.size - limit.measure(maxHeader)
+
+	type page struct {
+		lines  strings.Builder
+		size   int
+		alerts []Alert
+	}
+	pages := []*page{{}}
+
+	for _, entry := range entries {
+		current := pages[len(pages)-1]
+		line := truncateLine(entry.line, available, limit)
+		lineSize := limit.measure(line)
+
+		if current.size > 0 && current.size+lineSize > available {
+			current = &page{}
+			pages = append(pages, current)
+		}
+		current.lines.WriteString(line)
+		current.size += lineSize
+		current.alerts = append(current.alerts, entry.alert)
+	}
+
+	messages := make([]renderedMessage, 0, len(pages))
+	for i, p := range pages {
+		body := fmt.Sprintf("%s (part %d/%d)\n%s", header, i+1, len(pages), p.lines.String())
+		messages = append(messages, renderedMessage{body: body, alerts: p.alerts})
+	}
+	return messages
+}
+
+func truncateMessage(header string, entries []messageEntry, limit messageLimit) renderedMessage {
+	const footerFormat = "… and %d more alerts\n"
+
+	maxFooter := fmt.Sprintf(footerFormat, len(entries))
+	available := limit.size - limit.measure(header+"\n") - limit.measure(maxFooter)
+
+	var body strings.Builder
+	body.WriteString(header + "\n")
+
+	size, omitted := 0, 0
+	alerts := make([]Alert, 0, len(entries))
+	for _, entry := range entries {
+		alerts = append(alerts, entry.alert)
+		if entry.line == "" {
+			continue
+		}
+		lineSize := limit.measure(entry.line)
+		if omitted > 0 || size+lineSize > available {
+			omitted++
+			continue
+		}
+		body.WriteString(entry.line)
+		size += lineSize
+	}
+
+	if omitted > 0 {
+		fmt.Fprintf(&body, footerFormat, omitted)
+	}
+	return renderedMessage{body: body.String(), alerts: alerts}
+}
+
+// truncateLine shortens a single line so that it fits into max, keeping the
+// result valid UTF-8.
+func truncateLine(line string, max int, limit messageLimit) string {
+	const ellipsis = "…\n"
+
+	if line == "" || limit.measure(line) <= max {
+		return line
+	}
+
+	keep := max - limit.measure(ellipsis)
+	if keep <= 0 {
+		return ""
+	}
+	if limit.runes {
+		return string([]rune(line)[:keep]) + ellipsis
+	}
+	for keep > 0 && !utf8.RuneStart(line[keep]) {
+		keep--
+	}
+	return line[:keep] + ellipsis
+}
@@ -0,0 +1,128 @@
+package alertmanager
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+func alertsWithSummaries(n int, summary string) []Alert {
+	alerts := make([]Alert, 0, n)
+	for i := 0; i < n; i++ {
+		alerts = append(alerts, Alert{
+			Status:      "firing",
+			Labels:      map[string]string{"alertname": fmt.Sprintf("Alert%d", i)},
+			Annotations: map[string]string{"summary": fmt.Sprintf("%s %d", summary, i)},
+		})
+	}
+	return alerts
+}
+
+func TestTopicMessageLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		topic config.SNSTopicConfig
+		want  messageLimit
+	}{
+		{"default", config.SNSTopicConfig{}, messageLimit{size: snsMaxMessageBytes}},
+		{"sms", config.SNSTopicConfig{Protocol: "SMS"}, messageLimit{size: smsMaxMessageChars, runes: true}},
+		{"lower limit", config.SNSTopicConfig{MaxMessageSize: 1000}, messageLimit{size: 1000}},
+		{"higher limit is capped", config.SNSTopicConfig{Protocol: "sms", MaxMessageSize: 5000}, messageLimit{size: smsMaxMessageChars, runes: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topicMessageLimit(tt.topic); got != tt.want {
+				t.Errorf("topicMessageLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMessagesFitLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		topic        config.SNSTopicConfig
+		alerts       []Alert
+		wantMessages int
+	}{
+		{"fits", config.SNSTopicConfig{MaxMessageSize: 1000}, alertsWithSummaries(3, "CPU high"), 1},
+		{"split", config.SNSTopicConfig{MaxMessageSize: 200, SplitMode: splitModeSplit}, alertsWithSummaries(20, "CPU usage is above 90%"), 4},
+		{"split sms with multibyte summaries", config.SNSTopicConfig{Protocol: "sms", MaxMessageSize: 160}, alertsWithSummaries(10, "Температура выше нормы"), 3},
+		{"split with a line longer than the limit", config.SNSTopicConfig{MaxMessageSize: 100}, alertsWithSummaries(2, strings.Repeat("ü", 200)), 2},
+		{"truncate", config.SNSTopicConfig{MaxMessageSize: 200, SplitMode: splitModeTruncate}, alertsWithSummaries(20, "CPU usage is above 90%"), 1},
+		{"truncate sms", config.SNSTopicConfig{Protocol: "sms", MaxMessageSize: 160, SplitMode: splitModeTruncate}, alertsWithSummaries(10, "Температура выше нормы"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := topicMessageLimit(tt.topic)
+			messages := renderMessages("Alertname: Test", tt.alerts, tt.topic)
+
+			if len(messages) != tt.wantMessages {
+				t.Fatalf("got %d messages, want %d", len(messages), tt.wantMessages)
+			}
+
+			covered := 0
+			for i, message := range messages {
+				if size := limit.measure(message.body); size > limit.size {
+					t.Errorf("message %d has size %d, over the limit of %d", i, size, limit.size)
+				}
+				if !utf8.ValidString(message.body) {
+					t.Errorf("message %d is not valid UTF-8", i)
+				}
+				if len(messages) > 1 && !strings.Contains(message.body, fmt.Sprintf("(part %d/%d)", i+1, len(messages))) {
+					t.Errorf("message %d has no part counter: %q", i, message.body)
+				}
+				covered += len(message.alerts)
+			}
+			if covered != len(tt.alerts) {
+				t.Errorf("messages cover %d alerts, want %d", covered, len(tt.alerts))
+			}
+		})
+	}
+}
+
+func TestTruncateMessageFooter(t *testing.T) {
+	topic := config.SNSTopicConfig{MaxMessageSize: 200, SplitMode: splitModeTruncate}
+	alerts := alertsWithSummaries(20, "CPU usage is above 90%")
+
+	message := renderMessages("Alertname: Test", alerts, topic)[0]
+
+	shown := strings.Count(message.body, "• ")
+	want := fmt.Sprintf("… and %d more alerts\n", len(alerts)-shown)
+	if !strings.HasSuffix(message.body, want) {
+		t.Errorf("body = %q, want it to end with %q", message.body, want)
+	}
+}
+
+func TestTruncateLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		max   int
+		limit messageLimit
+		want  string
+	}{
+		{"fits", "• short\n", 20, messageLimit{}, "• short\n"},
+		{"bytes", "• abcdefghij\n", 10, messageLimit{}, "• ab…\n"},
+		{"bytes keep UTF-8 boundaries", "üüüüüü\n", 9, messageLimit{}, "üü…\n"},
+		{"runes", "üüüüüü\n", 5, messageLimit{runes: true}, "üüü…\n"},
+		{"no room", "• abcdefghij\n", 1, messageLimit{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateLine(tt.line, tt.max, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateLine() = %q, want %q", got, tt.want)
+			}
+			if tt.limit.measure(got) > tt.max {
+				t.Errorf("truncateLine() result has size %d, over %d", tt.limit.measure(got), tt.max)
+			}
+		})
+	}
+}
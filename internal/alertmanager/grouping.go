@@ -0,0 +1,223 @@
+package alertmanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+// groupByAll is the special group_by value that groups alerts by all of
+// their labels, as in Alertmanager.
+const groupByAll = "..."
+
+type groupID struct {
+	topic int
+	key   string
+}
+
+// aggrGroup collects the alerts of one group for one topic and keeps track
+// of when the group has to be flushed next, following Alertmanager's
+// group_wait, group_interval and repeat_interval semantics.
+type aggrGroup struct {
+	topic        config.SNSTopicConfig
+	key          string
+	labels       map[string]string
+	alerts       map[string]Alert
+	changed      bool
+	notified     bool
+	lastNotified time.Time
+	nextFlush    time.Time
+}
+
+func newAggrGroup(topic config.SNSTopicConfig, labels map[string]string, now time.Time) *aggrGroup {
+	return &aggrGroup{
+		topic:     topic,
+		key:       groupKey(labels),
+		labels:    labels,
+		alerts:    make(map[string]Alert),
+		nextFlush: now.Add(time.Duration(topic.GroupWaitSeconds) * time.Second),
+	}
+}
+
+// insert adds or updates an alert received at now and records whether this
+// changes the group compared to the last notification.
+func (g *aggrGroup) insert(alert Alert, now time.Time) {
+	alert.updatedAt = now
+	fp := labelsFingerprint(alert.Labels)
+	if old, ok := g.alerts[fp]; !ok || old.Status != alert.Status || old.StartsAt != alert.StartsAt {
+		g.changed = true
+	} else {
+		alert.receivedAt = old.receivedAt
+	}
+	g.alerts[fp] = alert
+}
+
+// markNotified records a notification of the group at now. It clears the
+// receipt times of the alerts, so that only the first notification about a
+// new alert state counts towards the delivery latency.
+func (g *aggrGroup) markNotified(now time.Time) {
+	g.notified = true
+	g.lastNotified = now
+	g.changed = false
+	for fp, alert := range g.alerts {
+		alert.receivedAt = time.Time{}
+		g.alerts[fp] = alert
+	}
+}
+
+// retry marks an alert whose notification failed as changed, so that the
+// next flush sends the group again.
+func (g *aggrGroup) retry(alert Alert) {
+	fp := labelsFingerprint(alert.Labels)
+	current := g.alerts[fp]
+	if current.receivedAt.IsZero() {
+		current.receivedAt = alert.receivedAt
+	}
+	g.alerts[fp] = current
+	g.changed = true
+}
+
+// pendingAlerts returns the alerts whose current state has not been notified
+// yet.
+func (g *aggrGroup) pendingAlerts() []Alert {
+	var pending []Alert
+	for _, alert := range g.sortedAlerts() {
+		if !alert.receivedAt.IsZero() {
+			pending = append(pending, alert)
+		}
+	}
+	return pending
+}
+
+// shouldNotify reports whether a flush at now has to send a notification:
+// on the first flush, when the group changed, or when firing alerts are due
+// to be repeated.
+func (g *aggrGroup) shouldNotify(now time.Time) bool {
+	if !g.notified || g.changed {
+		return true
+	}
+	repeatInterval := time.Duration(g.topic.RepeatIntervalSeconds) * time.Second
+	return g.hasFiring() && now.Sub(g.lastNotified) >= repeatInterval
+}
+
+func (g *aggrGroup) hasFiring() bool {
+	for _, alert := range g.alerts {
+		if alert.Status != "resolved" {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedAlerts returns the alerts of the group ordered by start time.
+func (g *aggrGroup) sortedAlerts() []Alert {
+	fingerprints := make([]string, 0, len(g.alerts))
+	for fp := range g.alerts {
+		fingerprints = append(fingerprints, fp)
+	}
+	sort.Slice(fingerprints, func(i, j int) bool {
+		a, b := g.alerts[fingerprints[i]], g.alerts[fingerprints[j]]
+		if a.StartsAt != b.StartsAt {
+			return a.StartsAt < b.StartsAt
+		}
+		return fingerprints[i] < fingerprints[j]
+	})
+
+	alerts := make([]Alert, 0, len(fingerprints))
+	for _, fp := range fingerprints {
+		alerts = append(alerts, g.alerts[fp])
+	}
+	return alerts
+}
+
+// expireStale drops firing alerts that have not been received again within
+// timeout and returns them. Their resolved message was lost, e.g. because it
+// was rejected or Alertmanager does not send resolved notifications, and
+// without expiry the group would keep repeating them forever.
+func (g *aggrGroup) expireStale(now time.Time, timeout time.Duration) []Alert {
+	var expired []Alert
+	for fp, alert := range g.alerts {
+		if alert.Status != "resolved" && now.Sub(alert.updatedAt) >= timeout {
+			expired = append(expired, alert)
+			delete(g.alerts, fp)
+		}
+	}
+	return expired
+}
+
+// removeResolved drops resolved alerts from the group and reports whether
+// the group is empty afterwards.
+func (g *aggrGroup) removeResolved() bool {
+	for fp, alert := range g.alerts {
+		if alert.Status == "resolved" {
+			delete(g.alerts, fp)
+		}
+	}
+	return len(g.alerts) == 0
+}
+
+func groupLabels(alert Alert, groupBy []string) map[string]string {
+	labels := make(map[string]string)
+	for _, name := range groupBy {
+		if name == groupByAll {
+			for k, v := range alert.Labels {
+				labels[k] = v
+			}
+			return labels
+		}
+		if value, ok := alert.Labels[name]; ok {
+			labels[name] = value
+		}
+	}
+	return labels
+}
+
+func sortedLabelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func groupKey(labels map[string]string) string {
+	var key strings.Builder
+	key.WriteString("{")
+	for i, name := range sortedLabelNames(labels) {
+		if i > 0 {
+			key.WriteString(",")
+		}
+		fmt.Fprintf(&key, "%s=%q", name, labels[name])
+	}
+	key.WriteString("}")
+	return key.String()
+}
+
+// formatGroupHeader renders the grouping labels of a message, leading with
+// the alert name when alerts are grouped by it.
+func formatGroupHeader(labels map[string]string) string {
+	var header strings.Builder
+
+	if alertname, ok := labels["alertname"]; ok {
+		fmt.Fprintf(&header, "Alertname: %s", alertname)
+	} else {
+		header.WriteString("Alerts")
+	}
+
+	var rest []string
+	for _, name := range sortedLabelNames(labels) {
+		if name == "alertname" {
+			continue
+		}
+		rest = append(rest, fmt.Sprintf("%s=%s", name, labels[name]))
+	}
+	if len(rest) > 0 {
+		fmt.Fprintf(&header, " {%s}", strings.Join(rest, ", "))
+	}
+
+	return header.String()
+}
@@ -0,0 +1,67 @@
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+func TestAggrGroupExpireStale(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timeout := time.Hour
+
+	tests := []struct {
+		name        string
+		status      string
+		refreshedAt time.Time
+		now         time.Time
+		wantExpired bool
+	}{
+		{"fresh firing alert", "firing", start, start.Add(30 * time.Minute), false},
+		{"firing alert at the timeout", "firing", start, start.Add(time.Hour), true},
+		{"firing alert refreshed before the timeout", "firing", start.Add(50 * time.Minute), start.Add(90 * time.Minute), false},
+		{"stale firing alert", "firing", start, start.Add(720 * time.Hour), true},
+		{"resolved alert is left to removeResolved", "resolved", start, start.Add(720 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topic := config.SNSTopicConfig{Name: "test", RepeatIntervalSeconds: 3600}
+			group := newAggrGroup(topic, map[string]string{"alertname": "HighLoad"}, start)
+
+			alert := Alert{Status: tt.status, Labels: map[string]string{"alertname": "HighLoad"}, StartsAt: start.Format(time.RFC3339)}
+			group.insert(alert, start)
+			if tt.refreshedAt != start {
+				group.insert(alert, tt.refreshedAt)
+			}
+
+			expired := group.expireStale(tt.now, timeout)
+			if got := len(expired) == 1; got != tt.wantExpired {
+				t.Fatalf("expired = %v, want %v", got, tt.wantExpired)
+			}
+			if got := len(group.alerts) == 0; got != tt.wantExpired {
+				t.Errorf("group empty = %v, want %v", got, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestAggrGroupShouldNotifyAfterExpiry(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	topic := config.SNSTopicConfig{Name: "test", RepeatIntervalSeconds: 3600}
+	group := newAggrGroup(topic, map[string]string{"alertname": "HighLoad"}, start)
+	group.insert(Alert{Status: "firing", Labels: map[string]string{"alertname": "HighLoad"}}, start)
+	group.notified = true
+	group.lastNotified = start
+	group.changed = false
+
+	now := start.Add(720 * time.Hour)
+	if !group.shouldNotify(now) {
+		t.Fatal("a firing group must repeat before its alerts expire")
+	}
+	group.expireStale(now, 12*time.Hour)
+	if group.shouldNotify(now) {
+		t.Error("a group whose alerts expired must not repeat")
+	}
+}
@@ -3,18 +3,30 @@ package alertmanager
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/maks3201/sns-alert-service/config"
 	"github.com/maks3201/sns-alert-service/internal/aws"
+	"github.com/maks3201/sns-alert-service/internal/delivery"
+	"github.com/maks3201/sns-alert-service/internal/nflog"
+	"github.com/maks3201/sns-alert-service/internal/web"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/maks3201/sns-alert-service/internal/alertmanager")
+
 type AlertmanagerPayload struct {
 	Receiver          string            `json:"receiver"`
 	Status            string            `json:"status"`
@@ -34,181 +46,582 @@ type Alert struct {
 	StartsAt     string            `json:"startsAt"`
 	EndsAt       string            `json:"endsAt"`
 	GeneratorURL string            `json:"generatorURL"`
+
+	// receivedAt is when the current state of the alert was first received.
+	// It is reset once the alert has been notified.
+	receivedAt time.Time
+	// spanContext identifies the span of the request that delivered the
+	// alert, to link notifications to it.
+	spanContext trace.SpanContext
+	// updatedAt is when the alert was last received, to expire alerts whose
+	// resolved message never arrives.
+	updatedAt time.Time
+	// requestID identifies the request that delivered the alert, to
+	// correlate its log lines through batching and publishing.
+	requestID string
+}
+
+// Silencer decides whether an alert is muted by a silence.
+type Silencer interface {
+	Mutes(labels map[string]string) bool
+}
+
+// Heartbeat consumes the heartbeat alerts of a dead man's switch.
+type Heartbeat interface {
+	Matches(labels map[string]string) bool
+	Beat(now time.Time)
 }
 
 type Handler struct {
-	cfg           config.Config
-	awsClient     aws.SNSClient
-	alertChan     chan Alert
-	batchMutex    sync.Mutex
-	pendingAlerts []Alert
+	cfg        config.Config
+	awsClient  aws.SNSClient
+	queue      *alertQueue
+	batchMutex sync.Mutex
+	filters    []filterRule
+	inhibitor  *inhibitor
+	silencer   Silencer
+	heartbeat  Heartbeat
+	groups     map[groupID]*aggrGroup
+	nflog      *nflog.Log
+	dispatcher *dispatcher
+	lastTick   atomic.Int64
 }
 
-func NewHandler(cfg config.Config, awsClient aws.SNSClient) *Handler {
-	return &Handler{
-		cfg:       cfg,
-		awsClient: awsClient,
-		alertChan: make(chan Alert, 100),
+func NewHandler(cfg config.Config, awsClient aws.SNSClient, silencer Silencer, heartbeat Heartbeat, history *delivery.History) (*Handler, error) {
+	filters, err := compileFilters(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filters: %v", err)
+	}
+
+	inhibitor, err := newInhibitor(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inhibit rules: %v", err)
 	}
+
+	notificationLog, err := nflog.Open(cfg.NotificationLogFile)
+	if err != nil {
+		return nil, err
+	}
+
+	alertnames.setLimit(cfg.Metrics.AlertnameCardinalityLimit)
+	queue := newAlertQueue(cfg.Queue)
+
+	h := &Handler{
+		cfg:        cfg,
+		awsClient:  awsClient,
+		queue:      queue,
+		filters:    filters,
+		inhibitor:  inhibitor,
+		silencer:   silencer,
+		heartbeat:  heartbeat,
+		groups:     make(map[groupID]*aggrGroup),
+		nflog:      notificationLog,
+		dispatcher: newDispatcher(cfg, awsClient, notificationLog, history, queue.spill),
+	}
+	h.lastTick.Store(time.Now().UnixNano())
+	return h, nil
 }
 
 func (h *Handler) SNSHandler(w http.ResponseWriter, r *http.Request) {
-	log.Infof("Loaded global alertnames: %v", h.cfg.AlertNames)
+	logger := web.Logger(r.Context())
+	logger.Debugf("Loaded global alertnames: %v", h.cfg.AlertNames)
 
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		log.Errorf("Error reading request body: %v", err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logger.Warnf("Rejected request from %s: body exceeds the limit of %d bytes", r.RemoteAddr, maxBytesErr.Limit)
+			web.WriteBodyTooLarge(w, maxBytesErr.Limit)
+			return
+		}
+		web.WriteError(w, http.StatusBadRequest, "failed to read request body")
+		logger.Errorf("Error reading request body: %v", err)
 		return
 	}
 	defer r.Body.Close()
 
+	if log.IsLevelEnabled(log.DebugLevel) {
+		logger.Debugf("Received alert: %s", string(bodyBytes))
+	}
+
 	var payload AlertmanagerPayload
 	if err := json.Unmarshal(bodyBytes, &payload); err != nil {
-		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
-		log.Errorf("Error parsing request: %v", err)
+		web.WriteError(w, http.StatusBadRequest, "failed to parse request body", err.Error())
+		logger.Errorf("Error parsing request: %v", err)
 		return
 	}
 
+	if problems := validatePayload(payload); len(problems) > 0 {
+		web.WriteError(w, http.StatusBadRequest, "invalid payload", problems...)
+		logger.Errorf("Rejected invalid payload from %s: %v", r.RemoteAddr, problems)
+		return
+	}
+
+	receivedAt := time.Now()
+	requestID := web.RequestIDFromContext(r.Context())
+	rejected := 0
 	for _, alert := range payload.Alerts {
-		AlertsReceived.Inc()
+		alert.receivedAt = receivedAt
+		alert.requestID = requestID
+		if !h.receiveAlert(r.Context(), alert) {
+			rejected++
+		}
+	}
+
+	if rejected > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(h.cfg.Queue.RetryAfterSeconds))
+		web.WriteError(w, http.StatusServiceUnavailable, fmt.Sprintf("alert queue is full, %d of %d alerts were not accepted", rejected, len(payload.Alerts)))
+		return
+	}
 
-		alertname := alert.Labels["alertname"]
-		log.Infof("Received alertname: %s", alertname)
-		log.Infof("Allowed alertnames: %v", h.cfg.AlertNames)
+	fmt.Fprintf(w, "Alerts received")
+}
 
-		if isAlertFiltered(alertname, h.cfg.AlertNames) {
-			log.Infof("Alertname %s is allowed", alertname)
-			h.alertChan <- alert
-		} else {
-			log.Infof("Alertname %s is filtered and will not be sent", alertname)
-			AlertsFiltered.Inc()
+// receiveAlert filters a received alert and queues it for batching. It
+// returns false if the alert was rejected because the queue is full.
+func (h *Handler) receiveAlert(ctx context.Context, alert Alert) bool {
+	alertname := alert.Labels["alertname"]
+	AlertsReceived.WithLabelValues(alertnameLabel(alert), alert.Status).Inc()
+
+	_, span := tracer.Start(ctx, "alert.enqueue", trace.WithAttributes(
+		attribute.String("alert.name", alertname),
+		attribute.String("alert.status", alert.Status),
+		attribute.String("alert.fingerprint", labelsFingerprint(alert.Labels)),
+	))
+	defer span.End()
+	alert.spanContext = span.SpanContext()
+
+	logger := web.Logger(ctx).WithField("alertname", alertname)
+
+	if h.heartbeat.Matches(alert.Labels) {
+		if alert.Status == "firing" {
+			h.heartbeat.Beat(time.Now())
 		}
+		span.SetAttributes(attribute.String("alert.outcome", "heartbeat"))
+		return true
 	}
 
-	fmt.Fprintf(w, "Alerts received")
+	h.inhibitor.observe(alert, time.Now())
+
+	logger.Debugf("Received alertname: %s", alertname)
+	logger.Debugf("Allowed alertnames: %v", h.cfg.AlertNames)
+
+	allowed, rule := evaluateFilters(h.filters, alert)
+	action := filterActionExclude
+	if allowed {
+		action = filterActionInclude
+	}
+	FilterRuleMatches.WithLabelValues(rule, action).Inc()
+	span.SetAttributes(attribute.String("alert.filter_rule", rule))
+
+	if !allowed {
+		logger.Infof("Alertname %s is filtered by rule %s and will not be sent", alertname, rule)
+		AlertsFiltered.WithLabelValues("", alertnameLabel(alert), filterReasonRule).Inc()
+		span.SetAttributes(attribute.String("alert.outcome", "filtered"))
+		return true
+	}
+
+	logger.Infof("Alertname %s is allowed by filter rule %s", alertname, rule)
+	if !h.queue.push(alert) {
+		logger.Warnf("Alert queue is full, rejecting alertname %s", alertname)
+		span.SetAttributes(attribute.String("alert.outcome", "rejected"))
+		span.SetStatus(codes.Error, "alert queue is full")
+		return false
+	}
+	span.SetAttributes(attribute.String("alert.outcome", "queued"))
+	return true
 }
 
+// ProcessBatches collects incoming alerts into per-topic groups and flushes
+// each group on its own schedule until ctx is cancelled. Pending alerts are
+// delivered by Drain afterwards.
 func (h *Handler) ProcessBatches(ctx context.Context) {
-	ticker := time.NewTicker(time.Duration(h.cfg.BatchWaitSeconds) * time.Second)
-	defer ticker.Stop()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	housekeeping := time.NewTicker(time.Second)
+	defer housekeeping.Stop()
+
+	h.dispatcher.start()
 
 	for {
+		h.lastTick.Store(time.Now().UnixNano())
+		h.resetFlushTimer(timer)
+
 		select {
 		case <-ctx.Done():
-			h.sendBatch()
 			return
-		case alert := <-h.alertChan:
-			h.batchMutex.Lock()
-			h.pendingAlerts = append(h.pendingAlerts, alert)
-			h.batchMutex.Unlock()
-		case <-ticker.C:
-			h.sendBatch()
+		case alert := <-h.queue.ch:
+			h.queue.updateMetrics()
+			h.addAlert(alert, time.Now())
+		case now := <-timer.C:
+			h.flushDue(now)
+		case job := <-h.dispatcher.failed:
+			h.requeue(job, time.Now())
+		case now := <-housekeeping.C:
+			h.restoreSpilled(now)
+			h.updatePendingAge(now)
+		}
+	}
+}
+
+// Drain delivers all alerts that are still queued, spilled or pending in a
+// group, and waits for the delivery queues to empty. It must be called after
+// ProcessBatches has returned and no new alerts are received. Alerts that
+// cannot be delivered before ctx is done are logged and, if a spill file is
+// configured, written to it to be delivered after the next start.
+func (h *Handler) Drain(ctx context.Context) {
+	now := time.Now()
+	queued := 0
+	for done := false; !done; {
+		select {
+		case alert := <-h.queue.ch:
+			h.addAlert(alert, now)
+			queued++
+		default:
+			done = true
 		}
 	}
+	h.queue.updateMetrics()
+	h.restoreSpilled(now)
+
+	log.Infof("Draining %d queued alerts and %d pending groups", queued, h.pendingGroups())
+
+	h.dispatcher.beginDrain(ctx)
+	h.flushAll()
+	h.dispatcher.stop(ctx)
 }
 
-func (h *Handler) sendBatch() {
+func (h *Handler) pendingGroups() int {
 	h.batchMutex.Lock()
-	if len(h.pendingAlerts) == 0 {
-		h.batchMutex.Unlock()
+	defer h.batchMutex.Unlock()
+	return len(h.groups)
+}
+
+// LastTick returns when the batching loop last ran. It runs at least once
+// per second while it is healthy.
+func (h *Handler) LastTick() time.Time {
+	return time.Unix(0, h.lastTick.Load())
+}
+
+// QueueUtilization returns the fill level of the alert queue from 0 to 1.
+func (h *Handler) QueueUtilization() float64 {
+	return float64(len(h.queue.ch)) / float64(cap(h.queue.ch))
+}
+
+// restoreSpilled moves alerts spilled to disk during an overload into their
+// groups once the queue is empty, so that they are grouped after the alerts
+// queued before them.
+func (h *Handler) restoreSpilled(now time.Time) {
+	if h.queue.spill == nil || len(h.queue.ch) > 0 || !h.queue.spill.hasPending() {
+		return
+	}
+
+	entries, err := h.queue.spill.drain()
+	if err != nil {
+		log.Errorf("Error restoring spilled alerts: %v", err)
 		return
 	}
+	if len(entries) == 0 {
+		return
+	}
+
+	log.Infof("Restoring %d alerts spilled to disk", len(entries))
+	for _, entry := range entries {
+		h.addAlertToTopic(entry.Alert, entry.TopicARN, now)
+	}
+}
+
+// updatePendingAge sets the age of the oldest alert that has been received
+// but not yet notified.
+func (h *Handler) updatePendingAge(now time.Time) {
+	h.batchMutex.Lock()
+	defer h.batchMutex.Unlock()
+
+	oldest := now
+	for _, group := range h.groups {
+		for _, alert := range group.alerts {
+			if !alert.receivedAt.IsZero() && alert.receivedAt.Before(oldest) {
+				oldest = alert.receivedAt
+			}
+		}
+	}
+	OldestPendingAlertAge.Set(now.Sub(oldest).Seconds())
+}
+
+// resetFlushTimer arms the timer for the earliest pending group flush.
+func (h *Handler) resetFlushTimer(timer *time.Timer) {
+	wait := time.Hour
 
-	alertsToSend := h.pendingAlerts
-	h.pendingAlerts = nil
+	h.batchMutex.Lock()
+	for _, group := range h.groups {
+		if d := time.Until(group.nextFlush); d < wait {
+			wait = d
+		}
+	}
 	h.batchMutex.Unlock()
 
-	groupedAlerts := groupAlertsByAlertname(alertsToSend)
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(max(wait, 0))
+}
 
-	for alertname, alerts := range groupedAlerts {
-		message := formatGroupedAlertMessage(alertname, alerts)
+func (h *Handler) addAlert(alert Alert, now time.Time) {
+	h.addAlertToTopic(alert, "", now)
+}
 
-		for _, topic := range h.cfg.Topics {
-			location := time.UTC
+// addAlertToTopic adds an alert to its group of the topic with the given
+// ARN, or of every topic if the ARN is empty.
+func (h *Handler) addAlertToTopic(alert Alert, topicARN string, now time.Time) {
+	h.batchMutex.Lock()
+	defer h.batchMutex.Unlock()
 
-			currentTime := time.Now().In(location)
-			year, month, day := currentTime.Date()
+	for i, topic := range h.cfg.Topics {
+		if topicARN != "" && topic.ARN != topicARN {
+			continue
+		}
+		labels := groupLabels(alert, topic.GroupBy)
+		id := groupID{topic: i, key: groupKey(labels)}
+
+		group, ok := h.groups[id]
+		if !ok {
+			group = newAggrGroup(topic, labels, now)
+			h.groups[id] = group
+			log.Debugf("Created group %s for topic %s", group.key, topic.Name)
+		}
+		group.insert(alert, now)
+	}
+}
 
-			startTimeParsed, err := time.ParseInLocation("15:04", topic.StartTime, location)
-			if err != nil {
-				log.Errorf("Error parsing start time: %v", err)
-				continue
-			}
-			startTime := time.Date(year, month, day, startTimeParsed.Hour(), startTimeParsed.Minute(), 0, 0, location)
+func (h *Handler) flushDue(now time.Time) {
+	h.inhibitor.gc(now)
 
-			endTimeParsed, err := time.ParseInLocation("15:04", topic.EndTime, location)
-			if err != nil {
-				log.Errorf("Error parsing end time: %v", err)
-				continue
-			}
-			endTime := time.Date(year, month, day, endTimeParsed.Hour(), endTimeParsed.Minute(), 0, 0, location)
+	h.batchMutex.Lock()
+	defer h.batchMutex.Unlock()
 
-			log.Infof("Current time: %s, Current day: %s", currentTime.Format("15:04"), currentTime.Weekday().String())
+	for id, group := range h.groups {
+		if !now.Before(group.nextFlush) {
+			h.flushGroup(id, group, now)
+		}
+	}
+}
 
-			if isTopicAvailable(startTime, endTime, currentTime, topic.DaysOfWeek) {
-				log.Infof("Topic %s is available. Sending batch alert to ARN: %s", topic.Name, topic.ARN)
+// flushAll sends every group that has alerts not notified yet. It is used
+// on shutdown, when waiting for the group timers is no longer possible.
+func (h *Handler) flushAll() {
+	h.batchMutex.Lock()
+	defer h.batchMutex.Unlock()
 
-				publishCtx, cancel := context.WithTimeout(context.Background(), time.Duration(h.cfg.Timeouts.AWS.APICallTimeoutSeconds)*time.Second)
-				defer cancel()
+	now := time.Now()
+	for id, group := range h.groups {
+		if !group.notified || group.changed {
+			h.flushGroup(id, group, now)
+		}
+	}
+}
 
-				startSend := time.Now()
-				err := h.awsClient.PublishToSNS(publishCtx, topic.ARN, message)
-				duration := time.Since(startSend).Seconds()
-				SNSSendDuration.Observe(duration)
+// flushGroup sends a notification for the group if one is due and schedules
+// the next flush after group_interval. The caller must hold batchMutex.
+func (h *Handler) flushGroup(id groupID, group *aggrGroup, now time.Time) {
+	topic := group.topic
+	group.nextFlush = now.Add(time.Duration(topic.GroupIntervalSeconds) * time.Second)
 
-				if err != nil {
-					log.Errorf("Error sending batch message to SNS: %v", err)
-					AlertsFailed.Add(float64(len(alerts)))
-					continue
-				}
+	for _, alert := range group.expireStale(now, time.Duration(h.cfg.ResolveTimeoutSeconds)*time.Second) {
+		log.WithField("request_id", alert.requestID).Infof("Alert %s of group %s was not received for %ds, considering it resolved", alert.Labels["alertname"], group.key, h.cfg.ResolveTimeoutSeconds)
+	}
+	if len(group.alerts) == 0 {
+		delete(h.groups, id)
+		return
+	}
 
-				AlertsSent.Add(float64(len(alerts)))
-				BatchesSent.Inc()
+	available, err := isTopicAvailableAt(topic, now)
+	if err != nil {
+		log.Errorf("Error checking time window of topic %s: %v", topic.Name, err)
+	}
 
-				log.Infof("Batch alert sent to SNS topic: %s", topic.ARN)
-			} else {
-				log.Infof("Topic %s is not available at this time.", topic.Name)
-				AlertsFiltered.Inc()
-			}
+	switch {
+	case !group.shouldNotify(now):
+	case !available:
+		// Notifications outside the time window are dropped, not deferred
+		// until the window opens.
+		log.WithField("request_ids", requestIDs(group.sortedAlerts())).Infof("Topic %s is not available at this time, not sending group %s", topic.Name, group.key)
+		// Repeated notifications are not counted, so that every alert state
+		// is counted once.
+		for _, alert := range group.pendingAlerts() {
+			AlertsFiltered.WithLabelValues(topic.Name, alertnameLabel(alert), filterReasonTimeWindow).Inc()
 		}
+		group.markNotified(now)
+	default:
+		h.sendBatch(id, group, now)
+		group.markNotified(now)
+	}
+
+	if group.removeResolved() {
+		delete(h.groups, id)
 	}
 }
 
-func groupAlertsByAlertname(alerts []Alert) map[string][]Alert {
-	grouped := make(map[string][]Alert)
+func (h *Handler) sendBatch(id groupID, group *aggrGroup, now time.Time) {
+	topic := group.topic
+	alerts := group.sortedAlerts()
+
+	// The flush starts a new trace, linked to the requests that delivered
+	// the alerts of the group.
+	links := make([]trace.Link, 0, len(alerts))
 	for _, alert := range alerts {
-		alertname := alert.Labels["alertname"]
-		grouped[alertname] = append(grouped[alertname], alert)
+		if alert.spanContext.IsValid() {
+			links = append(links, trace.Link{SpanContext: alert.spanContext})
+		}
+	}
+	_, span := tracer.Start(context.Background(), "alertmanager.flush",
+		trace.WithNewRoot(),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String("sns.topic", topic.Name),
+			attribute.String("alert.group", group.key),
+			attribute.Int("alert.count", len(alerts)),
+		),
+	)
+	defer span.End()
+
+	logger := log.WithFields(log.Fields{
+		"topic":       topic.Name,
+		"group":       group.key,
+		"request_ids": requestIDs(alerts),
+	})
+
+	alerts = h.inhibit(topic, alerts, now)
+	if len(alerts) == 0 {
+		logger.Infof("All alerts of group %s are inhibited or silenced, not sending to topic %s", group.key, topic.Name)
+		span.SetAttributes(attribute.String("alert.outcome", "inhibited"))
+		return
+	}
+
+	alerts = h.deduplicate(topic, alerts, now)
+	if len(alerts) == 0 {
+		logger.Infof("All alerts of group %s were already sent to topic %s, suppressing duplicate notification", group.key, topic.Name)
+		span.SetAttributes(attribute.String("alert.outcome", "deduplicated"))
+		return
+	}
+
+	logger.Infof("Queueing batch alert for group %s to ARN: %s", group.key, topic.ARN)
+
+	messages := renderMessages(formatGroupHeader(group.labels), alerts, topic)
+	if len(messages) > 1 {
+		logger.Infof("Batch for group %s exceeds the message limit of topic %s and was split into %d messages", group.key, topic.Name, len(messages))
+	}
+
+	span.SetAttributes(attribute.Int("sns.message.count", len(messages)))
+
+	for _, message := range messages {
+		job := deliveryJob{topic: topic, group: id, message: message.body, alerts: message.alerts, spanContext: span.SpanContext()}
+		if !h.dispatcher.enqueue(job) {
+			if h.dispatcher.drainCtx != nil {
+				h.dispatcher.undelivered(job, "shutdown deadline exceeded")
+				continue
+			}
+			logger.Errorf("Delivery queue for topic %s is full, dropping batch of %d alerts", topic.Name, len(message.alerts))
+			countFailed(topic.Name, message.alerts, failReasonQueueFull)
+			h.dispatcher.retry(job)
+		}
 	}
-	return grouped
 }
 
-func formatGroupedAlertMessage(alertname string, alerts []Alert) string {
-	var message strings.Builder
+// requeue marks the alerts of a job whose delivery failed as not notified
+// again, so that the next flush of their group sends them once more. Alerts
+// whose state has changed since are left alone, and resolved alerts that
+// were already removed from the group are put back.
+func (h *Handler) requeue(job deliveryJob, now time.Time) {
+	h.batchMutex.Lock()
+	defer h.batchMutex.Unlock()
 
-	fmt.Fprintf(&message, "Alertname: %s\n", alertname)
+	for _, alert := range job.alerts {
+		group, ok := h.groups[job.group]
+		current, found := Alert{}, false
+		if ok {
+			current, found = group.alerts[labelsFingerprint(alert.Labels)]
+		}
 
-	for _, alert := range alerts {
-		summary := alert.Annotations["summary"]
-		if summary != "" {
-			fmt.Fprintf(&message, "• %s\n", summary)
+		switch {
+		case found && current.Status == alert.Status && current.StartsAt == alert.StartsAt:
+			group.retry(alert)
+		case !found && alert.Status == "resolved":
+			if !ok {
+				group = newAggrGroup(job.topic, groupLabels(alert, job.topic.GroupBy), now)
+				h.groups[job.group] = group
+			}
+			group.insert(alert, now)
+		default:
+			continue
 		}
+		log.WithField("request_id", alert.requestID).Infof("Retrying alert %s of group %s with the next flush", alert.Labels["alertname"], group.key)
 	}
+}
 
-	return message.String()
+// inhibit drops alerts muted by an inhibition rule or by a silence created
+// after the alert was batched.
+func (h *Handler) inhibit(topic config.SNSTopicConfig, alerts []Alert, now time.Time) []Alert {
+	result := make([]Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if h.silencer.Mutes(alert.Labels) {
+			log.WithField("request_id", alert.requestID).Infof("Alert %s is silenced and will not be sent", alert.Labels["alertname"])
+			AlertsSilenced.WithLabelValues(alertnameLabel(alert)).Inc()
+			continue
+		}
+		if h.inhibitor.mutes(alert, now) {
+			log.WithField("request_id", alert.requestID).Infof("Alert %s is inhibited and will not be sent", alert.Labels["alertname"])
+			AlertsInhibited.WithLabelValues(topic.Name, alertnameLabel(alert)).Inc()
+			continue
+		}
+		result = append(result, alert)
+	}
+	return result
 }
 
-func isAlertFiltered(alertname string, allowedAlertNames []string) bool {
-	if len(allowedAlertNames) == 0 {
-		return true
+// deduplicate drops alerts that have already been delivered to the topic
+// with the same status within the topic's dedup TTL.
+func (h *Handler) deduplicate(topic config.SNSTopicConfig, alerts []Alert, now time.Time) []Alert {
+	if topic.DedupTTLSeconds <= 0 {
+		return alerts
 	}
-	for _, allowedAlert := range allowedAlertNames {
-		if allowedAlert == alertname {
-			return true
+
+	result := make([]Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if h.nflog.Seen(notificationKey(topic.ARN, alert), now) {
+			log.WithField("request_id", alert.requestID).Debugf("Alert %s was already sent to topic %s, suppressing duplicate", labelsFingerprint(alert.Labels), topic.Name)
+			AlertsDeduplicated.WithLabelValues(topic.Name, alertnameLabel(alert)).Inc()
+			continue
 		}
+		result = append(result, alert)
+	}
+	return result
+}
+
+func isTopicAvailableAt(topic config.SNSTopicConfig, now time.Time) (bool, error) {
+	location := time.UTC
+
+	currentTime := now.In(location)
+	year, month, day := currentTime.Date()
+
+	startTimeParsed, err := time.ParseInLocation("15:04", topic.StartTime, location)
+	if err != nil {
+		return false, fmt.Errorf("error parsing start time: %v", err)
 	}
-	return false
+	startTime := time.Date(year, month, day, startTimeParsed.Hour(), startTimeParsed.Minute(), 0, 0, location)
+
+	endTimeParsed, err := time.ParseInLocation("15:04", topic.EndTime, location)
+	if err != nil {
+		return false, fmt.Errorf("error parsing end time: %v", err)
+	}
+	endTime := time.Date(year, month, day, endTimeParsed.Hour(), endTimeParsed.Minute(), 0, 0, location)
+
+	log.Debugf("Current time: %s, Current day: %s", currentTime.Format("15:04"), currentTime.Weekday().String())
+
+	return isTopicAvailable(startTime, endTime, currentTime, topic.DaysOfWeek), nil
 }
 
 func isTopicAvailable(startTime, endTime, currentTime time.Time, daysOfWeek []string) bool {
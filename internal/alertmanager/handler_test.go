@@ -0,0 +1,277 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/aws"
+	"github.com/maks3201/sns-alert-service/internal/delivery"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeSNSClient struct {
+	mu        sync.Mutex
+	published []string
+	err       error
+}
+
+func (c *fakeSNSClient) PublishToSNS(ctx context.Context, topicArn string, message string) (aws.PublishResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return aws.PublishResult{}, c.err
+	}
+	c.published = append(c.published, message)
+	return aws.PublishResult{MessageID: "test"}, nil
+}
+
+func (c *fakeSNSClient) CheckSNSConnection(ctx context.Context) error {
+	return nil
+}
+
+type fakeSilencer struct {
+	muted bool
+}
+
+func (s *fakeSilencer) Mutes(labels map[string]string) bool {
+	return s.muted
+}
+
+type noHeartbeat struct{}
+
+func (noHeartbeat) Matches(labels map[string]string) bool { return false }
+func (noHeartbeat) Beat(now time.Time)                    {}
+
+func testConfig() config.Config {
+	return config.Config{
+		Topics: []config.SNSTopicConfig{{
+			Name:                  "test",
+			ARN:                   "arn:aws:sns:eu-central-1:123456789012:test",
+			StartTime:             "00:00",
+			EndTime:               "23:59",
+			GroupBy:               []string{"alertname"},
+			GroupWaitSeconds:      30,
+			GroupIntervalSeconds:  300,
+			RepeatIntervalSeconds: 3600,
+		}},
+		ResolveTimeoutSeconds: 12 * 60 * 60,
+		Queue:                 config.QueueConfig{Capacity: 10, OverloadPolicy: "reject"},
+		Metrics:               config.MetricsConfig{AlertnameCardinalityLimit: 100},
+		Delivery:              config.DeliveryConfig{Workers: 1, QueueSize: 10},
+	}
+}
+
+func newTestHandler(t *testing.T, cfg config.Config, silencer Silencer) *Handler {
+	t.Helper()
+	history, err := delivery.NewHistory(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(cfg, &fakeSNSClient{}, silencer, noHeartbeat{}, history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// receive passes an alert through receiveAlert and moves it from the queue
+// into its groups, as ProcessBatches does.
+func receive(t *testing.T, h *Handler, alert Alert, now time.Time) {
+	t.Helper()
+	if !h.receiveAlert(context.Background(), alert) {
+		t.Fatal("alert was rejected")
+	}
+	select {
+	case queued := <-h.queue.ch:
+		h.addAlert(queued, now)
+	default:
+	}
+}
+
+func TestSilencedAlertResolvesGroup(t *testing.T) {
+	silencer := &fakeSilencer{muted: true}
+	h := newTestHandler(t, testConfig(), silencer)
+	now := time.Now()
+
+	alert := Alert{Status: "firing", Labels: map[string]string{"alertname": "HighLoad"}, StartsAt: now.Format(time.RFC3339)}
+	receive(t, h, alert, now)
+	if len(h.groups) != 1 {
+		t.Fatalf("groups = %d, want the silenced alert to be grouped", len(h.groups))
+	}
+
+	alert.Status = "resolved"
+	receive(t, h, alert, now.Add(time.Minute))
+
+	silencer.muted = false
+	h.flushDue(now.Add(time.Hour))
+	if len(h.groups) != 0 {
+		t.Errorf("groups = %d, want the resolved group to be removed after the silence ended", len(h.groups))
+	}
+}
+
+func TestAlertsOutsideTimeWindowAreDropped(t *testing.T) {
+	cfg := testConfig()
+	cfg.Queue.SpillFile = filepath.Join(t.TempDir(), "spill.jsonl")
+	now := time.Now().UTC()
+	// A one-minute window that closed an hour ago.
+	cfg.Topics[0].StartTime = now.Add(-2 * time.Hour).Format("15:04")
+	cfg.Topics[0].EndTime = now.Add(-2*time.Hour + time.Minute).Format("15:04")
+
+	h := newTestHandler(t, cfg, &fakeSilencer{})
+	alert := Alert{Status: "firing", Labels: map[string]string{"alertname": "HighLoad"}, StartsAt: now.Format(time.RFC3339)}
+	alert.receivedAt = now
+	receive(t, h, alert, now)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	h.Drain(ctx)
+
+	for _, group := range h.groups {
+		if !group.notified || len(group.pendingAlerts()) > 0 {
+			t.Error("group outside the time window was not marked notified")
+		}
+	}
+	if published := h.awsClient.(*fakeSNSClient).published; len(published) != 0 {
+		t.Errorf("published %d messages outside the time window", len(published))
+	}
+	spilled, err := h.queue.spill.drain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spilled) != 0 {
+		t.Errorf("spilled = %v, want alerts outside the time window to be dropped", namesOf(spilledAlerts(spilled)))
+	}
+}
+
+// deliverQueued delivers the jobs queued for the test topic synchronously,
+// as the dispatcher workers do.
+func deliverQueued(h *Handler) {
+	queue := h.dispatcher.queues[testConfig().Topics[0].ARN]
+	for len(queue) > 0 {
+		h.dispatcher.deliver(<-queue)
+	}
+}
+
+func TestFailedDeliveryIsRetried(t *testing.T) {
+	tests := []struct {
+		name string
+		// status is the status of the alert when its delivery fails.
+		status string
+		// update is received after the failure and before the retry, if set.
+		update    string
+		wantRetry bool
+	}{
+		{name: "firing", status: "firing", wantRetry: true},
+		{name: "resolved", status: "resolved", wantRetry: true},
+		{name: "resolved after failed firing", status: "firing", update: "resolved", wantRetry: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, testConfig(), &fakeSilencer{})
+			client := h.awsClient.(*fakeSNSClient)
+			now := time.Now()
+
+			alert := Alert{Status: "firing", Labels: map[string]string{"alertname": "HighLoad"}, StartsAt: now.Format(time.RFC3339)}
+			receive(t, h, alert, now)
+			if tt.status == "resolved" {
+				now = now.Add(time.Minute)
+				h.flushDue(now)
+				deliverQueued(h)
+				alert.Status = "resolved"
+				receive(t, h, alert, now)
+			}
+
+			client.err = errors.New("throttled")
+			now = now.Add(5 * time.Minute)
+			h.flushDue(now)
+			deliverQueued(h)
+			client.err = nil
+
+			select {
+			case job := <-h.dispatcher.failed:
+				if tt.update != "" {
+					alert.Status = tt.update
+					receive(t, h, alert, now)
+					h.flushDue(now.Add(5 * time.Minute))
+					deliverQueued(h)
+				}
+				h.requeue(job, now)
+			default:
+				t.Fatal("failed job was not handed back for a retry")
+			}
+
+			sent := len(client.published)
+			h.flushDue(now.Add(15 * time.Minute))
+			deliverQueued(h)
+			if retried := len(client.published) > sent; retried != tt.wantRetry {
+				t.Errorf("retried = %v, want %v", retried, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestTimeWindowFilteredOncePerAlertState(t *testing.T) {
+	cfg := testConfig()
+	now := time.Now().UTC()
+	// A one-minute window that closed an hour ago.
+	cfg.Topics[0].StartTime = now.Add(-2 * time.Hour).Format("15:04")
+	cfg.Topics[0].EndTime = now.Add(-2*time.Hour + time.Minute).Format("15:04")
+	cfg.Topics[0].RepeatIntervalSeconds = 60
+
+	h := newTestHandler(t, cfg, &fakeSilencer{})
+	counter := AlertsFiltered.WithLabelValues("test", "HighLoad", filterReasonTimeWindow)
+	before := testutil.ToFloat64(counter)
+
+	alert := Alert{Status: "firing", Labels: map[string]string{"alertname": "HighLoad"}, StartsAt: now.Format(time.RFC3339)}
+	alert.receivedAt = now
+	receive(t, h, alert, now)
+	for i := 1; i <= 3; i++ {
+		h.flushDue(now.Add(time.Duration(i) * time.Hour))
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("filtered count = %v, want 1", got)
+	}
+}
+
+func TestRestoreSpilledAlertsIntoTheirTopic(t *testing.T) {
+	cfg := testConfig()
+	cfg.Queue.SpillFile = filepath.Join(t.TempDir(), "spill.jsonl")
+	other := cfg.Topics[0]
+	other.Name = "other"
+	other.ARN = "arn:aws:sns:eu-central-1:123456789012:other"
+	cfg.Topics = append(cfg.Topics, other)
+
+	h := newTestHandler(t, cfg, &fakeSilencer{})
+	now := time.Now()
+	undelivered := Alert{Status: "firing", Labels: map[string]string{"alertname": "Undelivered"}, StartsAt: now.Format(time.RFC3339)}
+	undelivered.receivedAt = now
+	h.dispatcher.undelivered(deliveryJob{topic: other, alerts: []Alert{undelivered}}, "shutdown deadline exceeded")
+	if err := h.queue.spill.append("", []Alert{alertNamed("Overload")}); err != nil {
+		t.Fatal(err)
+	}
+
+	h.restoreSpilled(now)
+
+	got := make(map[string][]int)
+	for id, group := range h.groups {
+		for _, alert := range group.alerts {
+			got[alert.Labels["alertname"]] = append(got[alert.Labels["alertname"]], id.topic)
+		}
+	}
+	for _, topics := range got {
+		sort.Ints(topics)
+	}
+	want := map[string][]int{"Undelivered": {1}, "Overload": {0, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("restored alerts by topic = %v, want %v", got, want)
+	}
+}
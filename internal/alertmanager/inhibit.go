@@ -0,0 +1,130 @@
+package alertmanager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/matchers"
+)
+
+// inhibitRule mutes alerts matching the target matchers while an alert
+// matching the source matchers is firing and both have the same values for
+// the equal labels.
+type inhibitRule struct {
+	source matchers.Matchers
+	target matchers.Matchers
+	equal  []string
+}
+
+// inhibitor tracks the alerts currently firing, as seen in the received
+// payloads, and evaluates the inhibition rules against them.
+type inhibitor struct {
+	rules          []inhibitRule
+	resolveTimeout time.Duration
+
+	mu     sync.Mutex
+	active map[string]Alert
+}
+
+func newInhibitor(cfg config.Config) (*inhibitor, error) {
+	rules := make([]inhibitRule, 0, len(cfg.InhibitRules))
+	for i, ruleCfg := range cfg.InhibitRules {
+		source, err := matchers.ParseAll(ruleCfg.SourceMatchers)
+		if err != nil {
+			return nil, fmt.Errorf("inhibit rule %d: source matchers: %v", i, err)
+		}
+		target, err := matchers.ParseAll(ruleCfg.TargetMatchers)
+		if err != nil {
+			return nil, fmt.Errorf("inhibit rule %d: target matchers: %v", i, err)
+		}
+		rules = append(rules, inhibitRule{source: source, target: target, equal: ruleCfg.Equal})
+	}
+
+	return &inhibitor{
+		rules:          rules,
+		resolveTimeout: time.Duration(cfg.ResolveTimeoutSeconds) * time.Second,
+		active:         make(map[string]Alert),
+	}, nil
+}
+
+// observe updates the set of firing alerts with an alert received at now.
+func (i *inhibitor) observe(alert Alert, now time.Time) {
+	if len(i.rules) == 0 {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	fp := labelsFingerprint(alert.Labels)
+	if alert.Status == "resolved" {
+		delete(i.active, fp)
+		return
+	}
+	alert.updatedAt = now
+	i.active[fp] = alert
+}
+
+// mutes reports whether the alert is inhibited by a firing source alert.
+func (i *inhibitor) mutes(alert Alert, now time.Time) bool {
+	if len(i.rules) == 0 {
+		return false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	fp := labelsFingerprint(alert.Labels)
+	for _, rule := range i.rules {
+		if !rule.target.Matches(alert.Labels) {
+			continue
+		}
+		for sourceFp, source := range i.active {
+			if sourceFp == fp || i.isExpired(source, now) {
+				continue
+			}
+			if rule.source.Matches(source.Labels) && equalLabels(rule.equal, source.Labels, alert.Labels) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gc forgets firing alerts that expired without a resolved notification.
+func (i *inhibitor) gc(now time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for fp, alert := range i.active {
+		if i.isExpired(alert, now) {
+			delete(i.active, fp)
+		}
+	}
+}
+
+func equalLabels(names []string, a, b map[string]string) bool {
+	for _, name := range names {
+		if a[name] != b[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// isExpired reports whether a firing alert has an end time in the past or
+// has not been received again within the resolve timeout. Alertmanager sends
+// a zero end time for alerts that are still firing, so a source whose
+// resolved message was lost only expires by the resolve timeout.
+func (i *inhibitor) isExpired(alert Alert, now time.Time) bool {
+	if now.Sub(alert.updatedAt) >= i.resolveTimeout {
+		return true
+	}
+	endsAt, err := time.Parse(time.RFC3339, alert.EndsAt)
+	if err != nil || endsAt.IsZero() {
+		return false
+	}
+	return endsAt.Before(now)
+}
@@ -0,0 +1,56 @@
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+func TestInhibitorExpiresSources(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := config.Config{
+		ResolveTimeoutSeconds: 60 * 60,
+		InhibitRules: []config.InhibitRule{{
+			SourceMatchers: []string{`severity="critical"`},
+			TargetMatchers: []string{`severity="warning"`},
+			Equal:          []string{"cluster"},
+		}},
+	}
+
+	source := Alert{Status: "firing", Labels: map[string]string{"alertname": "ClusterDown", "severity": "critical", "cluster": "a"}}
+	target := Alert{Status: "firing", Labels: map[string]string{"alertname": "HighLoad", "severity": "warning", "cluster": "a"}}
+
+	tests := []struct {
+		name       string
+		source     Alert
+		now        time.Time
+		wantMuted  bool
+		wantActive int
+	}{
+		{"firing source", source, start.Add(30 * time.Minute), true, 1},
+		{"source past the resolve timeout", source, start.Add(30 * 24 * time.Hour), false, 0},
+		{"source with a past endsAt", Alert{Status: "firing", Labels: source.Labels, EndsAt: start.Add(time.Minute).Format(time.RFC3339)}, start.Add(30 * time.Minute), false, 0},
+		{"resolved source", Alert{Status: "resolved", Labels: source.Labels}, start, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inhibitor, err := newInhibitor(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inhibitor.observe(source, start)
+			inhibitor.observe(tt.source, start)
+
+			if got := inhibitor.mutes(target, tt.now); got != tt.wantMuted {
+				t.Errorf("mutes() = %v, want %v", got, tt.wantMuted)
+			}
+			inhibitor.gc(tt.now)
+			if got := len(inhibitor.active); got != tt.wantActive {
+				t.Errorf("active sources after gc = %d, want %d", got, tt.wantActive)
+			}
+		})
+	}
+}
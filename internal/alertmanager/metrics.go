@@ -1,51 +1,187 @@
 package alertmanager
 
 import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/smithy-go"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+const (
+	// Values of the reason label of sns_alerts_filtered_total.
+	filterReasonRule       = "filter_rule"
+	filterReasonTimeWindow = "time_window"
+
+	// Values of the reason label of sns_alerts_failed_total.
+	failReasonPublish   = "publish_error"
+	failReasonQueueFull = "queue_full"
+	failReasonShutdown  = "shutdown"
+
+	// otherAlertname replaces alertnames beyond the cardinality limit.
+	otherAlertname = "other"
+)
+
 var (
-	AlertsReceived = prometheus.NewCounter(
+	AlertsReceived = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "sns_alerts_received_total",
 			Help: "Total number of alerts received",
 		},
+		[]string{"alertname", "status"},
 	)
 
-	AlertsFiltered = prometheus.NewCounter(
+	AlertsFiltered = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "sns_alerts_filtered_total",
 			Help: "Total number of alerts filtered and not sent",
 		},
+		[]string{"topic", "alertname", "reason"},
 	)
 
-	AlertsSent = prometheus.NewCounter(
+	AlertsSent = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "sns_alerts_sent_total",
 			Help: "Total number of alerts sent to AWS SNS",
 		},
+		[]string{"topic", "alertname", "status"},
 	)
 
-	AlertsFailed = prometheus.NewCounter(
+	AlertsFailed = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "sns_alerts_failed_total",
 			Help: "Total number of alerts failed to send to AWS SNS",
 		},
+		[]string{"topic", "alertname", "reason"},
 	)
 
-	BatchesSent = prometheus.NewCounter(
+	BatchesSent = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "sns_batches_sent_total",
 			Help: "Total number of alert batches sent to AWS SNS",
 		},
+		[]string{"topic"},
 	)
 
-	SNSSendDuration = prometheus.NewHistogram(
+	SNSSendDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "sns_send_duration_seconds",
 			Help:    "Duration of sending alerts to AWS SNS",
 			Buckets: prometheus.DefBuckets,
 		},
+		[]string{"topic"},
+	)
+
+	AlertDeliveryLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sns_alert_delivery_latency_seconds",
+			Help:    "Time from receiving an alert to its successful publish to AWS SNS",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 14),
+		},
+		[]string{"topic"},
+	)
+
+	AlertAgeAtPublish = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sns_alert_age_at_publish_seconds",
+			Help:    "Time from the start of an alert to its successful publish to AWS SNS",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 10),
+		},
+		[]string{"topic"},
+	)
+
+	BatchSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sns_batch_size_alerts",
+			Help:    "Number of alerts in each message sent to AWS SNS",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		},
+		[]string{"topic"},
+	)
+
+	OldestPendingAlertAge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sns_oldest_pending_alert_age_seconds",
+			Help: "Time since the oldest alert that has not been notified yet was received",
+		},
+	)
+
+	SNSPublishErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_publish_errors_total",
+			Help: "Total number of failed Publish calls to AWS SNS by error code",
+		},
+		[]string{"topic", "code"},
+	)
+
+	AlertsDeduplicated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_alerts_deduplicated_total",
+			Help: "Total number of alerts suppressed as duplicates of an already delivered notification",
+		},
+		[]string{"topic", "alertname"},
+	)
+
+	AlertsInhibited = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_alerts_inhibited_total",
+			Help: "Total number of alerts suppressed by inhibition rules",
+		},
+		[]string{"topic", "alertname"},
+	)
+
+	AlertsSilenced = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_alerts_silenced_total",
+			Help: "Total number of alerts suppressed by local silences",
+		},
+		[]string{"alertname"},
+	)
+
+	FilterRuleMatches = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_filter_rule_matches_total",
+			Help: "Total number of alerts decided by each filter rule",
+		},
+		[]string{"rule", "action"},
+	)
+
+	AlertQueueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sns_alert_queue_length",
+			Help: "Number of received alerts waiting to be batched",
+		},
+	)
+
+	AlertQueueCapacity = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sns_alert_queue_capacity",
+			Help: "Capacity of the queue of received alerts",
+		},
+	)
+
+	AlertsRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_alerts_rejected_total",
+			Help: "Total number of alerts rejected or dropped because the alert queue was full",
+		},
+		[]string{"policy"},
+	)
+
+	AlertsSpilled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "sns_alerts_spilled_total",
+			Help: "Total number of alerts spilled to disk because the alert queue was full",
+		},
+	)
+
+	TopicQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sns_topic_queue_depth",
+			Help: "Number of messages waiting in the delivery queue of each SNS topic",
+		},
+		[]string{"topic"},
 	)
 )
 
@@ -56,4 +192,79 @@ func init() {
 	prometheus.MustRegister(AlertsFailed)
 	prometheus.MustRegister(BatchesSent)
 	prometheus.MustRegister(SNSSendDuration)
+	prometheus.MustRegister(SNSPublishErrors)
+	prometheus.MustRegister(AlertDeliveryLatency)
+	prometheus.MustRegister(AlertAgeAtPublish)
+	prometheus.MustRegister(BatchSize)
+	prometheus.MustRegister(OldestPendingAlertAge)
+	prometheus.MustRegister(TopicQueueDepth)
+	prometheus.MustRegister(AlertsDeduplicated)
+	prometheus.MustRegister(FilterRuleMatches)
+	prometheus.MustRegister(AlertsInhibited)
+	prometheus.MustRegister(AlertsSilenced)
+	prometheus.MustRegister(AlertQueueLength)
+	prometheus.MustRegister(AlertQueueCapacity)
+	prometheus.MustRegister(AlertsRejected)
+	prometheus.MustRegister(AlertsSpilled)
+}
+
+// alertnameLimiter bounds the number of distinct alertname label values.
+// Once the limit is reached, alertnames not seen before are reported as
+// "other".
+type alertnameLimiter struct {
+	mu    sync.Mutex
+	limit int
+	seen  map[string]struct{}
+}
+
+var alertnames = &alertnameLimiter{
+	limit: 100,
+	seen:  make(map[string]struct{}),
+}
+
+func (l *alertnameLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+}
+
+func (l *alertnameLimiter) label(alertname string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.seen[alertname]; ok {
+		return alertname
+	}
+	if len(l.seen) >= l.limit {
+		return otherAlertname
+	}
+	l.seen[alertname] = struct{}{}
+	return alertname
+}
+
+func alertnameLabel(alert Alert) string {
+	return alertnames.label(alert.Labels["alertname"])
+}
+
+// countFailed adds the alerts of a message to sns_alerts_failed_total.
+func countFailed(topic string, alerts []Alert, reason string) {
+	for _, alert := range alerts {
+		AlertsFailed.WithLabelValues(topic, alertnameLabel(alert), reason).Inc()
+	}
+}
+
+// errorCode returns the AWS error code of a failed API call, e.g.
+// "AuthorizationError" or "ThrottlingException".
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.ErrorCode()
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Timeout"
+	case errors.Is(err, context.Canceled):
+		return "Canceled"
+	default:
+		return "Unknown"
+	}
 }
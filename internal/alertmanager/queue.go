@@ -0,0 +1,188 @@
+package alertmanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	overloadPolicyReject     = "reject"
+	overloadPolicyDropOldest = "drop_oldest"
+	overloadPolicySpill      = "spill"
+)
+
+// alertQueue buffers received alerts between the HTTP handlers and the
+// batching loop. When it is full, the overload policy decides whether new
+// alerts are rejected, the oldest queued alerts are dropped, or new alerts
+// are spilled to disk, so that handlers never block.
+type alertQueue struct {
+	ch     chan Alert
+	policy string
+	spill  *spillFile
+
+	mu sync.Mutex
+}
+
+func newAlertQueue(cfg config.QueueConfig) *alertQueue {
+	q := &alertQueue{
+		ch:     make(chan Alert, cfg.Capacity),
+		policy: cfg.OverloadPolicy,
+	}
+	if cfg.SpillFile != "" {
+		q.spill = &spillFile{path: cfg.SpillFile}
+		if info, err := os.Stat(cfg.SpillFile); err == nil && info.Size() > 0 {
+			q.spill.pending = true
+		}
+	}
+	AlertQueueCapacity.Set(float64(cfg.Capacity))
+	return q
+}
+
+// push enqueues an alert without blocking. It returns false if the alert
+// was rejected.
+func (q *alertQueue) push(alert Alert) bool {
+	defer q.updateMetrics()
+
+	// While spilled alerts wait to be restored, new alerts follow them into
+	// the spill file, so that they are not grouped before older ones.
+	if q.policy != overloadPolicySpill || !q.spill.hasPending() {
+		select {
+		case q.ch <- alert:
+			return true
+		default:
+		}
+	}
+
+	switch q.policy {
+	case overloadPolicyDropOldest:
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for {
+			select {
+			case q.ch <- alert:
+				return true
+			default:
+			}
+			select {
+			case dropped := <-q.ch:
+				log.Warnf("Alert queue is full, dropping oldest alert %s", dropped.Labels["alertname"])
+				AlertsRejected.WithLabelValues(overloadPolicyDropOldest).Inc()
+			default:
+			}
+		}
+	case overloadPolicySpill:
+		if err := q.spill.append("", []Alert{alert}); err != nil {
+			log.Errorf("Alert queue is full and spilling to disk failed: %v", err)
+			AlertsRejected.WithLabelValues(overloadPolicySpill).Inc()
+			return false
+		}
+		AlertsSpilled.Inc()
+		return true
+	default:
+		AlertsRejected.WithLabelValues(overloadPolicyReject).Inc()
+		return false
+	}
+}
+
+func (q *alertQueue) updateMetrics() {
+	AlertQueueLength.Set(float64(len(q.ch)))
+}
+
+// spillFile stores alerts that did not fit into the queue as JSON lines.
+type spillFile struct {
+	path string
+
+	mu      sync.Mutex
+	pending bool
+}
+
+// hasPending reports whether the file holds alerts that were not restored
+// yet.
+func (s *spillFile) hasPending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// append writes alerts to the file. Alerts that were already grouped carry
+// the ARN of the topic they were meant for, so that they are only restored
+// into that topic's groups; an empty ARN restores them for all topics.
+func (s *spillFile) append(topicARN string, alerts []Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open spill file '%s': %v", s.path, err)
+	}
+
+	encoder := json.NewEncoder(f)
+	for _, alert := range alerts {
+		if err := encoder.Encode(spillEntry{Alert: alert, TopicARN: topicARN, ReceivedAt: alert.receivedAt, RequestID: alert.requestID}); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write spill file '%s': %v", s.path, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write spill file '%s': %v", s.path, err)
+	}
+	s.pending = true
+	return nil
+}
+
+// spillEntry is an alert as stored in the spill file, along with the topic
+// it was meant for, the time it was received and the ID of the request that
+// delivered it.
+type spillEntry struct {
+	Alert
+	TopicARN   string    `json:"topicARN,omitempty"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	RequestID  string    `json:"requestID,omitempty"`
+}
+
+// drain returns all spilled entries and truncates the file.
+func (s *spillFile) drain() ([]spillEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.pending = false
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill file '%s': %v", s.path, err)
+	}
+	defer f.Close()
+
+	var entries []spillEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var entry spillEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Errorf("Skipping corrupted entry in spill file '%s': %v", s.path, err)
+			continue
+		}
+		entry.Alert.receivedAt = entry.ReceivedAt
+		entry.Alert.requestID = entry.RequestID
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read spill file '%s': %v", s.path, err)
+	}
+
+	if err := os.Truncate(s.path, 0); err != nil {
+		return nil, fmt.Errorf("failed to truncate spill file '%s': %v", s.path, err)
+	}
+	s.pending = false
+	return entries, nil
+}
@@ -0,0 +1,98 @@
+package alertmanager
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+func alertNamed(name string) Alert {
+	return Alert{Status: "firing", Labels: map[string]string{"alertname": name}}
+}
+
+func spilledAlerts(entries []spillEntry) []Alert {
+	alerts := make([]Alert, 0, len(entries))
+	for _, entry := range entries {
+		alerts = append(alerts, entry.Alert)
+	}
+	return alerts
+}
+
+func namesOf(alerts []Alert) []string {
+	names := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		names = append(names, alert.Labels["alertname"])
+	}
+	return names
+}
+
+func TestAlertQueueOverloadPolicies(t *testing.T) {
+	tests := []struct {
+		policy     string
+		wantPushed []bool
+		wantQueued []string
+	}{
+		{overloadPolicyReject, []bool{true, true, false}, []string{"a", "b"}},
+		{overloadPolicyDropOldest, []bool{true, true, true}, []string{"b", "c"}},
+		{overloadPolicySpill, []bool{true, true, true}, []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			queue := newAlertQueue(config.QueueConfig{
+				Capacity:       2,
+				OverloadPolicy: tt.policy,
+				SpillFile:      filepath.Join(t.TempDir(), "spill.jsonl"),
+			})
+
+			var pushed []bool
+			for _, name := range []string{"a", "b", "c"} {
+				pushed = append(pushed, queue.push(alertNamed(name)))
+			}
+			if !reflect.DeepEqual(pushed, tt.wantPushed) {
+				t.Errorf("push() = %v, want %v", pushed, tt.wantPushed)
+			}
+
+			var queued []Alert
+			for len(queue.ch) > 0 {
+				queued = append(queued, <-queue.ch)
+			}
+			if got := namesOf(queued); !reflect.DeepEqual(got, tt.wantQueued) {
+				t.Errorf("queued = %v, want %v", got, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestAlertQueueSpillKeepsOrder(t *testing.T) {
+	queue := newAlertQueue(config.QueueConfig{
+		Capacity:       1,
+		OverloadPolicy: overloadPolicySpill,
+		SpillFile:      filepath.Join(t.TempDir(), "spill.jsonl"),
+	})
+
+	queue.push(alertNamed("a"))
+	queue.push(alertNamed("b"))
+	<-queue.ch
+
+	// The queue has room again, but "c" must not overtake the spilled "b".
+	queue.push(alertNamed("c"))
+	if len(queue.ch) != 0 {
+		t.Fatal("alert was queued while spilled alerts were pending")
+	}
+
+	spilled, err := queue.spill.drain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := namesOf(spilledAlerts(spilled)); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("spilled = %v, want [b c]", got)
+	}
+
+	queue.push(alertNamed("d"))
+	if len(queue.ch) != 1 {
+		t.Error("alert was not queued after the spill file was restored")
+	}
+}
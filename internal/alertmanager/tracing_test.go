@@ -0,0 +1,191 @@
+package alertmanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/aws"
+	"github.com/maks3201/sns-alert-service/internal/delivery"
+	"github.com/maks3201/sns-alert-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fakeSNSAPI struct {
+	aws.SNSAPI
+}
+
+func (f *fakeSNSAPI) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	return &sns.PublishOutput{MessageId: awssdk.String("message-1")}, nil
+}
+
+type noSilences struct{}
+
+func (noSilences) Mutes(labels map[string]string) bool { return false }
+
+type noHeartbeats struct{}
+
+func (noHeartbeats) Matches(labels map[string]string) bool { return false }
+
+func (noHeartbeats) Beat(now time.Time) {}
+
+func tracingTestConfig() config.Config {
+	cfg := config.Config{
+		Topics: []config.SNSTopicConfig{{
+			Name:                  "test",
+			ARN:                   "arn:aws:sns:eu-central-1:123456789012:test",
+			StartTime:             "00:00",
+			EndTime:               "23:59",
+			GroupBy:               []string{"alertname"},
+			GroupWaitSeconds:      30,
+			GroupIntervalSeconds:  300,
+			RepeatIntervalSeconds: 3600,
+		}},
+		ResolveTimeoutSeconds: 12 * 60 * 60,
+		Queue:                 config.QueueConfig{Capacity: 10, OverloadPolicy: "reject"},
+		Metrics:               config.MetricsConfig{AlertnameCardinalityLimit: 100},
+		Delivery:              config.DeliveryConfig{Workers: 1, QueueSize: 10},
+	}
+	cfg.Timeouts.AWS.APICallTimeoutSeconds = 5
+	return cfg
+}
+
+func spansNamed(spans tracetest.SpanStubs, name string) []tracetest.SpanStub {
+	var result []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == name {
+			result = append(result, span)
+		}
+	}
+	return result
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
+
+// The package tracers delegate to the first global tracer provider, so all
+// tests share one provider.
+var (
+	exporter     = tracetest.NewInMemoryExporter()
+	provider     = tracing.NewProvider(exporter, config.TracingConfig{ServiceName: "test", SampleRatio: 1})
+	providerOnce sync.Once
+)
+
+func TestFlushTracing(t *testing.T) {
+	providerOnce.Do(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	// Drop the spans of other tests.
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	exporter.Reset()
+
+	cfg := tracingTestConfig()
+	history, err := delivery.NewHistory(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(cfg, aws.NewClient(&fakeSNSAPI{}, cfg), noSilences{}, noHeartbeats{}, history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := tracing.Middleware("/alert", http.HandlerFunc(h.SNSHandler))
+
+	now := time.Now()
+	for _, instance := range []string{"a", "b"} {
+		body := fmt.Sprintf(`{"version":"4","status":"firing","alerts":[{"status":"firing","labels":{"alertname":"HighLoad","instance":%q},"startsAt":%q}]}`,
+			instance, now.Format(time.RFC3339))
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/alert", strings.NewReader(body)))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	for len(h.queue.ch) > 0 {
+		h.addAlert(<-h.queue.ch, now)
+	}
+	h.flushDue(now.Add(time.Minute))
+	for queue := h.dispatcher.queues[cfg.Topics[0].ARN]; len(queue) > 0; {
+		h.dispatcher.deliver(<-queue)
+	}
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.GetSpans()
+
+	requests := spansNamed(spans, "POST /alert")
+	enqueues := spansNamed(spans, "alert.enqueue")
+	flushes := spansNamed(spans, "alertmanager.flush")
+	publishes := spansNamed(spans, "sns.Publish")
+	if len(requests) != 2 || len(enqueues) != 2 || len(flushes) != 1 || len(publishes) != 1 {
+		t.Fatalf("got %d request, %d enqueue, %d flush and %d publish spans, want 2, 2, 1 and 1",
+			len(requests), len(enqueues), len(flushes), len(publishes))
+	}
+
+	requestSpans := make(map[trace.SpanID]bool)
+	for _, request := range requests {
+		requestSpans[request.SpanContext.SpanID()] = true
+	}
+	enqueueSpans := make(map[trace.SpanID]bool)
+	for _, enqueue := range enqueues {
+		if !requestSpans[enqueue.Parent.SpanID()] {
+			t.Errorf("enqueue span %s is not a child of a request span", enqueue.SpanContext.SpanID())
+		}
+		enqueueSpans[enqueue.SpanContext.SpanID()] = true
+	}
+
+	flush := flushes[0]
+	if flush.Parent.IsValid() {
+		t.Error("flush span is not the root of a new trace")
+	}
+	linked := make(map[trace.SpanID]bool)
+	for _, link := range flush.Links {
+		linked[link.SpanContext.SpanID()] = true
+	}
+	for id := range enqueueSpans {
+		if !linked[id] {
+			t.Errorf("flush span does not link to enqueue span %s", id)
+		}
+	}
+
+	publish := publishes[0]
+	if publish.Parent.SpanID() != flush.SpanContext.SpanID() {
+		t.Error("publish span is not a child of the flush span")
+	}
+	if publish.SpanKind != trace.SpanKindProducer {
+		t.Errorf("publish span kind = %v, want producer", publish.SpanKind)
+	}
+	for _, want := range []attribute.KeyValue{
+		attribute.String("messaging.message.id", "message-1"),
+		attribute.String("messaging.destination.name", cfg.Topics[0].ARN),
+		attribute.String("messaging.system", "aws_sns"),
+	} {
+		if !hasAttribute(publish.Attributes, want) {
+			t.Errorf("publish span attributes do not contain %v", want)
+		}
+	}
+	if !hasAttribute(flush.Attributes, attribute.String("sns.topic", cfg.Topics[0].Name)) {
+		t.Errorf("flush span attributes do not contain the topic")
+	}
+}
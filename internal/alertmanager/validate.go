@@ -0,0 +1,63 @@
+package alertmanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// supportedPayloadVersions are the webhook payload versions that share the
+// Alertmanager format: "4" from Alertmanager and "1" from Grafana alerting.
+var supportedPayloadVersions = map[string]bool{
+	"1": true,
+	"4": true,
+}
+
+// validatePayload checks a webhook payload and returns a description of
+// every problem found.
+func validatePayload(payload AlertmanagerPayload) []string {
+	var problems []string
+
+	if !supportedPayloadVersions[payload.Version] {
+		problems = append(problems, fmt.Sprintf("unsupported version %q, expected one of %s", payload.Version, supportedVersionList()))
+	}
+	if !validStatus(payload.Status) {
+		problems = append(problems, fmt.Sprintf("invalid status %q, expected \"firing\" or \"resolved\"", payload.Status))
+	}
+	if len(payload.Alerts) == 0 {
+		problems = append(problems, "alerts must not be empty")
+	}
+
+	for i, alert := range payload.Alerts {
+		if !validStatus(alert.Status) {
+			problems = append(problems, fmt.Sprintf("alerts[%d]: invalid status %q, expected \"firing\" or \"resolved\"", i, alert.Status))
+		}
+		if len(alert.Labels) == 0 {
+			problems = append(problems, fmt.Sprintf("alerts[%d]: labels must not be empty", i))
+		}
+		if _, err := time.Parse(time.RFC3339, alert.StartsAt); err != nil {
+			problems = append(problems, fmt.Sprintf("alerts[%d]: invalid startsAt %q, expected RFC3339", i, alert.StartsAt))
+		}
+		if alert.EndsAt != "" {
+			if _, err := time.Parse(time.RFC3339, alert.EndsAt); err != nil {
+				problems = append(problems, fmt.Sprintf("alerts[%d]: invalid endsAt %q, expected RFC3339", i, alert.EndsAt))
+			}
+		}
+	}
+
+	return problems
+}
+
+func validStatus(status string) bool {
+	return status == "firing" || status == "resolved"
+}
+
+func supportedVersionList() string {
+	versions := make([]string, 0, len(supportedPayloadVersions))
+	for version := range supportedPayloadVersions {
+		versions = append(versions, fmt.Sprintf("%q", version))
+	}
+	sort.Strings(versions)
+	return strings.Join(versions, ", ")
+}
@@ -0,0 +1,51 @@
+package alertmanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidatePayload(t *testing.T) {
+	alert := Alert{
+		Status:   "firing",
+		Labels:   map[string]string{"alertname": "HighLoad"},
+		StartsAt: "2024-05-01T12:00:00Z",
+		EndsAt:   "0001-01-01T00:00:00Z",
+	}
+
+	tests := []struct {
+		name    string
+		payload AlertmanagerPayload
+		want    []string
+	}{
+		{"alertmanager", AlertmanagerPayload{Version: "4", Status: "firing", Alerts: []Alert{alert}}, nil},
+		{"grafana", AlertmanagerPayload{Version: "1", Status: "firing", Alerts: []Alert{alert}}, nil},
+		{
+			"unknown version",
+			AlertmanagerPayload{Version: "2", Status: "firing", Alerts: []Alert{alert}},
+			[]string{`unsupported version "2", expected one of "1", "4"`},
+		},
+		{
+			"invalid status and no alerts",
+			AlertmanagerPayload{Version: "4", Status: "pending"},
+			[]string{`invalid status "pending", expected "firing" or "resolved"`, "alerts must not be empty"},
+		},
+		{
+			"invalid alert",
+			AlertmanagerPayload{Version: "4", Status: "firing", Alerts: []Alert{{Status: "firing", StartsAt: "yesterday", EndsAt: "tomorrow"}}},
+			[]string{
+				"alerts[0]: labels must not be empty",
+				`alerts[0]: invalid startsAt "yesterday", expected RFC3339`,
+				`alerts[0]: invalid endsAt "tomorrow", expected RFC3339`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validatePayload(tt.payload); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validatePayload() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
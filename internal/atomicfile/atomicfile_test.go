@@ -0,0 +1,58 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		data     string
+	}{
+		{"new file", "", `{"a":1}`},
+		{"replace existing file", `{"old":true}`, `{"a":2}`},
+		{"empty data", `{"old":true}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "state.json")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := Write(path, []byte(tt.data)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.data {
+				t.Errorf("file = %q, want %q", got, tt.data)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("directory has %d entries, want the temporary file to be removed", len(entries))
+			}
+		})
+	}
+}
+
+func TestWriteMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "state.json")
+	if err := Write(path, []byte("{}")); err == nil {
+		t.Error("Write() into a missing directory succeeded")
+	}
+}
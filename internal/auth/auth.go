@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/web"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Authenticator checks the credentials of requests to one endpoint against
+// static bearer tokens and bcrypt-hashed basic auth passwords, matching what
+// Alertmanager's http_config can send.
+type Authenticator struct {
+	endpoint string
+	tokens   [][]byte
+	users    map[string][]byte
+	// dummyHash is compared against for unknown users, so that they take
+	// as long to reject as known users with a wrong password.
+	dummyHash []byte
+}
+
+// New creates an authenticator for the endpoint, reading the bearer tokens
+// from the configured files.
+func New(endpoint string, cfg config.EndpointAuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		endpoint: endpoint,
+		users:    make(map[string][]byte),
+	}
+
+	for _, path := range cfg.BearerTokenFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bearer token file '%s': %v", path, err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return nil, fmt.Errorf("bearer token file '%s' is empty", path)
+		}
+		a.tokens = append(a.tokens, []byte(token))
+	}
+
+	for user, hash := range cfg.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("invalid bcrypt hash for user '%s': %v", user, err)
+		}
+		a.users[user] = []byte(hash)
+	}
+
+	if len(a.users) > 0 {
+		dummyHash, err := bcrypt.GenerateFromPassword([]byte("unknown user"), maxCost(a.users))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dummy password hash: %v", err)
+		}
+		a.dummyHash = dummyHash
+	}
+
+	if a.Enabled() {
+		log.Infof("Authentication enabled for %s endpoint: %d bearer tokens, %d basic auth users", endpoint, len(a.tokens), len(a.users))
+	}
+	return a, nil
+}
+
+// Enabled reports whether any credentials are configured.
+func (a *Authenticator) Enabled() bool {
+	return len(a.tokens) > 0 || len(a.users) > 0
+}
+
+// Middleware rejects requests without valid credentials with 401. If no
+// credentials are configured, requests are passed through unchanged.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	if !a.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.authenticate(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		web.Logger(r.Context()).Warnf("Rejected unauthenticated request to %s from %s", r.URL.Path, r.RemoteAddr)
+		AuthRejected.WithLabelValues(a.endpoint).Inc()
+
+		if len(a.users) > 0 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="alertmanager-sns-forwarder"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) bool {
+	if user, password, ok := r.BasicAuth(); ok {
+		hash, known := a.users[user]
+		if !known {
+			bcrypt.CompareHashAndPassword(a.dummyHash, []byte(password))
+			return false
+		}
+		return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+	}
+
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	for _, expected := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), expected) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// maxCost returns the highest bcrypt cost of the hashes.
+func maxCost(hashes map[string][]byte) int {
+	result := bcrypt.MinCost
+	for _, hash := range hashes {
+		if cost, err := bcrypt.Cost(hash); err == nil && cost > result {
+			result = cost
+		}
+	}
+	return result
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func writeTokenFile(t *testing.T, token string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func hashPassword(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.EndpointAuthConfig
+		wantErr     bool
+		wantEnabled bool
+	}{
+		{"no credentials", config.EndpointAuthConfig{}, false, false},
+		{"token file", config.EndpointAuthConfig{BearerTokenFiles: []string{writeTokenFile(t, "secret\n")}}, false, true},
+		{"missing token file", config.EndpointAuthConfig{BearerTokenFiles: []string{"/nonexistent/token"}}, true, false},
+		{"empty token file", config.EndpointAuthConfig{BearerTokenFiles: []string{writeTokenFile(t, " \n")}}, true, false},
+		{"bcrypt user", config.EndpointAuthConfig{BasicAuthUsers: map[string]string{"alertmanager": hashPassword(t, "pw")}}, false, true},
+		{"plain text password", config.EndpointAuthConfig{BasicAuthUsers: map[string]string{"alertmanager": "pw"}}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New("alert", tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && a.Enabled() != tt.wantEnabled {
+				t.Errorf("Enabled() = %v, want %v", a.Enabled(), tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	alertAuth, err := New("alert", config.EndpointAuthConfig{
+		BearerTokenFiles: []string{writeTokenFile(t, "alert-token"), writeTokenFile(t, "rotated-token")},
+		BasicAuthUsers:   map[string]string{"alertmanager": hashPassword(t, "alert-password")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiAuth, err := New("api", config.EndpointAuthConfig{
+		BearerTokenFiles: []string{writeTokenFile(t, "api-token")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statusAuth, err := New("status", config.EndpointAuthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		auth          *Authenticator
+		setup         func(r *http.Request)
+		wantStatus    int
+		wantChallenge string
+	}{
+		{"bearer token", alertAuth, func(r *http.Request) { r.Header.Set("Authorization", "Bearer alert-token") }, http.StatusOK, ""},
+		{"second bearer token", alertAuth, func(r *http.Request) { r.Header.Set("Authorization", "bearer rotated-token") }, http.StatusOK, ""},
+		{"wrong bearer token", alertAuth, func(r *http.Request) { r.Header.Set("Authorization", "Bearer api-token") }, http.StatusUnauthorized, "Basic"},
+		{"basic auth", alertAuth, func(r *http.Request) { r.SetBasicAuth("alertmanager", "alert-password") }, http.StatusOK, ""},
+		{"wrong password", alertAuth, func(r *http.Request) { r.SetBasicAuth("alertmanager", "wrong") }, http.StatusUnauthorized, "Basic"},
+		{"unknown user", alertAuth, func(r *http.Request) { r.SetBasicAuth("grafana", "alert-password") }, http.StatusUnauthorized, "Basic"},
+		{"no credentials", alertAuth, func(r *http.Request) {}, http.StatusUnauthorized, "Basic"},
+		{"token of another endpoint", apiAuth, func(r *http.Request) { r.Header.Set("Authorization", "Bearer alert-token") }, http.StatusUnauthorized, "Bearer"},
+		{"endpoint token", apiAuth, func(r *http.Request) { r.Header.Set("Authorization", "Bearer api-token") }, http.StatusOK, ""},
+		{"endpoint without credentials", statusAuth, func(r *http.Request) {}, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			rejected := testutil.ToFloat64(AuthRejected.WithLabelValues(tt.auth.endpoint))
+
+			r := httptest.NewRequest(http.MethodPost, "/alert", nil)
+			tt.setup(r)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, r)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("WWW-Authenticate"); !strings.HasPrefix(got, tt.wantChallenge) {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+			wantRejected := 0.0
+			if tt.wantStatus == http.StatusUnauthorized {
+				wantRejected = 1
+			}
+			if got := testutil.ToFloat64(AuthRejected.WithLabelValues(tt.auth.endpoint)) - rejected; got != wantRejected {
+				t.Errorf("rejected count = %v, want %v", got, wantRejected)
+			}
+		})
+	}
+}
+
+func TestUnknownUserIsComparedAgainstDummyHash(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost+2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New("alert", config.EndpointAuthConfig{BasicAuthUsers: map[string]string{
+		"alertmanager": hashPassword(t, "pw"),
+		"grafana":      string(hash),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown users must cost as much as the most expensive known user.
+	if cost, err := bcrypt.Cost(a.dummyHash); err != nil || cost != bcrypt.MinCost+2 {
+		t.Errorf("dummy hash cost = %d (%v), want %d", cost, err, bcrypt.MinCost+2)
+	}
+}
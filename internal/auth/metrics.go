@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	AuthRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_http_auth_rejected_total",
+			Help: "Total number of HTTP requests rejected because of missing or invalid credentials",
+		},
+		[]string{"endpoint"},
+	)
+
+	SignatureRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_webhook_signature_rejected_total",
+			Help: "Total number of webhooks rejected because of a missing, invalid or replayed signature",
+		},
+		[]string{"reason"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(AuthRejected)
+	prometheus.MustRegister(SignatureRejected)
+}
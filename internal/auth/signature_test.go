@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+func sign(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTestVerifier() *SignatureVerifier {
+	return &SignatureVerifier{
+		cfg:    config.SignatureConfig{MaxAgeSeconds: 300},
+		maxAge: 300 * time.Second,
+		sources: []signatureSource{
+			{name: "grafana", secret: []byte("grafana-secret")},
+			{name: "partner", secret: []byte("partner-secret")},
+		},
+		seen: make(map[string]time.Time),
+	}
+}
+
+func TestSignatureVerifierVerify(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := `{"status":"firing"}`
+	signature := sign("grafana-secret", timestamp, body)
+
+	tests := []struct {
+		name       string
+		signature  string
+		source     string
+		timestamp  string
+		body       string
+		wantSource string
+		wantReason string
+	}{
+		{"valid", signature, "grafana", timestamp, body, "grafana", ""},
+		{"valid without source header", signature, "", timestamp, body, "grafana", ""},
+		{"sha256 prefix", "sha256=" + signature, "grafana", timestamp, body, "grafana", ""},
+		{"RFC3339 timestamp", sign("grafana-secret", now.Format(time.RFC3339), body), "grafana", now.Format(time.RFC3339), body, "grafana", ""},
+		{"missing signature", "", "grafana", timestamp, body, "", "missing_signature"},
+		{"invalid timestamp", signature, "grafana", "yesterday", body, "", "invalid_timestamp"},
+		{"expired timestamp", sign("grafana-secret", "1000", body), "grafana", "1000", body, "", "expired_timestamp"},
+		{"timestamp in the future", signature, "grafana", strconv.FormatInt(now.Add(time.Hour).Unix(), 10), body, "", "expired_timestamp"},
+		{"not hex", "zz", "grafana", timestamp, body, "", "invalid_signature"},
+		{"tampered body", signature, "grafana", timestamp, `{"status":"resolved"}`, "", "invalid_signature"},
+		{"secret of another source", signature, "partner", timestamp, body, "", "invalid_signature"},
+		{"unknown source", signature, "unknown", timestamp, body, "", "unknown_source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, reason := newTestVerifier().verify(tt.signature, tt.source, tt.timestamp, []byte(tt.body), now)
+			if source != tt.wantSource || reason != tt.wantReason {
+				t.Errorf("verify() = (%q, %q), want (%q, %q)", source, reason, tt.wantSource, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSignatureVerifierReplay(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := `{"status":"firing"}`
+	signature := sign("grafana-secret", timestamp, body)
+
+	tests := []struct {
+		name   string
+		replay string
+	}{
+		{"same signature", signature},
+		{"uppercase hex", strings.ToUpper(signature)},
+		{"added sha256 prefix", "sha256=" + signature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier()
+			if _, reason := v.verify(signature, "grafana", timestamp, []byte(body), now); reason != "" {
+				t.Fatalf("first request rejected: %s", reason)
+			}
+			if _, reason := v.verify(tt.replay, "grafana", timestamp, []byte(body), now.Add(time.Second)); reason != "replayed" {
+				t.Errorf("replay reason = %q, want %q", reason, "replayed")
+			}
+		})
+	}
+}
+
+func TestSignatureVerifierReplayExpires(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := sign("grafana-secret", timestamp, "{}")
+
+	later := now.Add(11 * time.Minute)
+	laterTimestamp := strconv.FormatInt(later.Unix(), 10)
+
+	v := newTestVerifier()
+	v.verify(signature, "grafana", timestamp, []byte("{}"), now)
+	v.verify(sign("grafana-secret", laterTimestamp, "{}"), "grafana", laterTimestamp, []byte("{}"), later)
+	if len(v.seen) != 1 {
+		t.Errorf("seen = %d entries, want only the signature within the window", len(v.seen))
+	}
+}
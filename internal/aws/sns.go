@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -16,16 +17,29 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/smithy-go"
 	"github.com/maks3201/sns-alert-service/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/maks3201/sns-alert-service/internal/aws")
+
 type SNSAPI interface {
 	ListTopics(ctx context.Context, params *sns.ListTopicsInput, optFns ...func(*sns.Options)) (*sns.ListTopicsOutput, error)
 	GetTopicAttributes(ctx context.Context, params *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error)
 	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
 }
 
+// PublishResult identifies a message accepted by SNS.
+type PublishResult struct {
+	MessageID string
+	// SequenceNumber is only set for FIFO topics.
+	SequenceNumber string
+}
+
 type SNSClient interface {
-	PublishToSNS(ctx context.Context, topicArn string, message string) error
+	PublishToSNS(ctx context.Context, topicArn string, message string) (PublishResult, error)
 	CheckSNSConnection(ctx context.Context) error
 }
 
@@ -35,6 +49,26 @@ type Client struct {
 }
 
 func InitSNSClient(cfg config.Config) (*Client, error) {
+	client, err := NewClientForRegion(cfg, cfg.AWSRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.verifySNSClient(cfg.AWSRegion); err != nil {
+		return nil, fmt.Errorf("failed to verify SNS client: %v", err)
+	}
+
+	if err := client.CheckSNSTopicsExistence(cfg); err != nil {
+		return nil, fmt.Errorf("SNS topics verification failed: %v", err)
+	}
+
+	return client, nil
+}
+
+// NewClientForRegion creates an SNS client for the given region with the
+// configured credentials and timeouts, without verifying the connection or
+// the configured topics.
+func NewClientForRegion(cfg config.Config, region string) (*Client, error) {
 	customHTTPClient := &http.Client{
 		Transport: &http.Transport{
 			DialContext: (&net.Dialer{
@@ -56,14 +90,14 @@ func InitSNSClient(cfg config.Config) (*Client, error) {
 		creds := credentials.NewStaticCredentialsProvider(cfg.AWSAccessKey, cfg.AWSSecretKey, "")
 		awsCfg, err = awsconfig.LoadDefaultConfig(
 			context.TODO(),
-			awsconfig.WithRegion(cfg.AWSRegion),
+			awsconfig.WithRegion(region),
 			awsconfig.WithHTTPClient(customHTTPClient),
 			awsconfig.WithCredentialsProvider(creds),
 		)
 	} else {
 		awsCfg, err = awsconfig.LoadDefaultConfig(
 			context.TODO(),
-			awsconfig.WithRegion(cfg.AWSRegion),
+			awsconfig.WithRegion(region),
 			awsconfig.WithHTTPClient(customHTTPClient),
 		)
 	}
@@ -72,20 +106,15 @@ func InitSNSClient(cfg config.Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to load AWS configuration: %v", err)
 	}
 
-	client := &Client{
-		snsClient: sns.NewFromConfig(awsCfg),
-		cfg:       cfg,
-	}
-
-	if err := client.verifySNSClient(cfg.AWSRegion); err != nil {
-		return nil, fmt.Errorf("failed to verify SNS client: %v", err)
-	}
+	return NewClient(sns.NewFromConfig(awsCfg), cfg), nil
+}
 
-	if err := client.CheckSNSTopicsExistence(cfg); err != nil {
-		return nil, fmt.Errorf("SNS topics verification failed: %v", err)
+// NewClient creates a client that calls SNS through snsClient.
+func NewClient(snsClient SNSAPI, cfg config.Config) *Client {
+	return &Client{
+		snsClient: snsClient,
+		cfg:       cfg,
 	}
-
-	return client, nil
 }
 
 // The rest of the code remains unchanged.
@@ -105,7 +134,7 @@ func (c *Client) verifySNSClient(region string) error {
 
 func (c *Client) CheckSNSTopicsExistence(cfg config.Config) error {
 	for _, topic := range cfg.Topics {
-		exists, err := c.TopicExists(topic.ARN)
+		exists, err := c.TopicExists(context.Background(), topic.ARN)
 		if err != nil {
 			return fmt.Errorf("error checking topic %s: %v", topic.Name, err)
 		}
@@ -117,9 +146,12 @@ func (c *Client) CheckSNSTopicsExistence(cfg config.Config) error {
 	return nil
 }
 
-func (c *Client) TopicExists(topicArn string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.cfg.Timeouts.AWS.APICallTimeoutSeconds)*time.Second)
-	defer cancel()
+func (c *Client) TopicExists(ctx context.Context, topicArn string) (bool, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.cfg.Timeouts.AWS.APICallTimeoutSeconds)*time.Second)
+		defer cancel()
+	}
 
 	input := &sns.GetTopicAttributesInput{
 		TopicArn: aws.String(topicArn),
@@ -137,7 +169,18 @@ func (c *Client) TopicExists(topicArn string) (bool, error) {
 	return true, nil
 }
 
-func (c *Client) PublishToSNS(ctx context.Context, topicArn string, message string) error {
+// emptyMessageError is the message of the InvalidParameter error SNS
+// returns for a publish with an empty message.
+const emptyMessageError = "Empty message"
+
+// CheckPublishPermission verifies that the client may publish to the topic
+// without delivering a message, by publishing an empty message. It relies on
+// SNS authorizing the request before validating the message, which is how
+// SNS behaves but is not documented: an authorized client gets the
+// InvalidParameter "Empty message" error, and an unauthorized one an
+// AuthorizationError. Any other outcome, including other InvalidParameter
+// errors, is reported as a failure rather than assumed to be a permission.
+func (c *Client) CheckPublishPermission(ctx context.Context, topicArn string) error {
 	if _, ok := ctx.Deadline(); !ok {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.cfg.Timeouts.AWS.APICallTimeoutSeconds)*time.Second)
@@ -145,13 +188,56 @@ func (c *Client) PublishToSNS(ctx context.Context, topicArn string, message stri
 	}
 
 	_, err := c.snsClient.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(topicArn),
+		Message:  aws.String(""),
+	})
+	if err == nil {
+		return nil
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidParameter" && strings.Contains(apiErr.ErrorMessage(), emptyMessageError) {
+		return nil
+	}
+	return fmt.Errorf("publish permission check failed: %w", err)
+}
+
+func (c *Client) PublishToSNS(ctx context.Context, topicArn string, message string) (PublishResult, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.cfg.Timeouts.AWS.APICallTimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	ctx, span := tracer.Start(ctx, "sns.Publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "aws_sns"),
+			attribute.String("messaging.destination.name", topicArn),
+			attribute.Int("messaging.message.body.size", len(message)),
+		),
+	)
+	defer span.End()
+
+	output, err := c.snsClient.Publish(ctx, &sns.PublishInput{
 		TopicArn: aws.String(topicArn),
 		Message:  aws.String(message),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to publish message to SNS: %v", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return PublishResult{}, fmt.Errorf("failed to publish message to SNS: %w", err)
 	}
-	return nil
+
+	result := PublishResult{
+		MessageID:      aws.ToString(output.MessageId),
+		SequenceNumber: aws.ToString(output.SequenceNumber),
+	}
+	span.SetAttributes(attribute.String("messaging.message.id", result.MessageID))
+	if result.SequenceNumber != "" {
+		span.SetAttributes(attribute.String("aws.sns.sequence_number", result.SequenceNumber))
+	}
+	return result, nil
 }
 
 func (c *Client) CheckSNSConnection(ctx context.Context) error {
@@ -0,0 +1,48 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/smithy-go"
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+type fakeSNSAPI struct {
+	SNSAPI
+	publishErr error
+}
+
+func (f *fakeSNSAPI) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	if f.publishErr != nil {
+		return nil, f.publishErr
+	}
+	return &sns.PublishOutput{}, nil
+}
+
+func TestCheckPublishPermission(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{"empty message rejected", &smithy.GenericAPIError{Code: "InvalidParameter", Message: "Invalid parameter: Empty message"}, false},
+		{"other invalid parameter", &smithy.GenericAPIError{Code: "InvalidParameter", Message: "Invalid parameter: TopicArn"}, true},
+		{"authorization error", &smithy.GenericAPIError{Code: "AuthorizationError", Message: "not authorized to perform: SNS:Publish"}, true},
+		{"network error", errors.New("connection refused"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{snsClient: &fakeSNSAPI{publishErr: tt.err}, cfg: config.Config{}}
+			client.cfg.Timeouts.AWS.APICallTimeoutSeconds = 1
+
+			err := client.CheckPublishPermission(context.Background(), "arn:aws:sns:eu-central-1:123456789012:test")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckPublishPermission() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package delivery
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/internal/web"
+	log "github.com/sirupsen/logrus"
+)
+
+// Query selects delivery records. Empty fields match all records.
+type Query struct {
+	Alertname string
+	// Topic matches the topic name or ARN.
+	Topic string
+	Since time.Time
+	Limit int
+}
+
+func (q Query) matches(r Record) bool {
+	if q.Alertname != "" && !r.HasAlert(q.Alertname) {
+		return false
+	}
+	if q.Topic != "" && q.Topic != r.Topic && q.Topic != r.TopicARN {
+		return false
+	}
+	return q.Since.IsZero() || !r.Time.Before(q.Since)
+}
+
+// Find returns the records matching the query, newest first.
+func (h *History) Find(q Query) []Record {
+	result := []Record{}
+	for _, record := range h.List() {
+		if q.Limit > 0 && len(result) >= q.Limit {
+			break
+		}
+		if q.matches(record) {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// parseQuery reads the alertname, topic, since and limit parameters. since
+// is either an RFC3339 timestamp or a duration before now, such as "2h".
+func parseQuery(r *http.Request, now time.Time) (Query, error) {
+	params := r.URL.Query()
+	q := Query{
+		Alertname: params.Get("alertname"),
+		Topic:     params.Get("topic"),
+	}
+
+	if since := strings.TrimSpace(params.Get("since")); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			q.Since = t
+		} else if d, err := time.ParseDuration(since); err == nil {
+			q.Since = now.Add(-d)
+		} else {
+			return q, fmt.Errorf("invalid since %q, expected an RFC3339 timestamp or a duration", since)
+		}
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid limit %q", limit)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// RegisterHandlers adds the /api/v1/deliveries endpoint to mux.
+func RegisterHandlers(mux *http.ServeMux, history *History) {
+	mux.HandleFunc("GET /api/v1/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseQuery(r, time.Now())
+		if err != nil {
+			web.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		web.WriteJSON(w, http.StatusOK, history.Find(q))
+	})
+}
+
+// PageHandler serves a minimal HTML page listing the recent deliveries,
+// filtered by the same parameters as the API.
+func PageHandler(history *History) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := pageData{
+			Alertname: r.URL.Query().Get("alertname"),
+			Topic:     r.URL.Query().Get("topic"),
+			Since:     r.URL.Query().Get("since"),
+		}
+
+		q, err := parseQuery(r, time.Now())
+		if err != nil {
+			data.Error = err.Error()
+		} else {
+			if q.Limit == 0 {
+				q.Limit = 200
+			}
+			data.Records = history.Find(q)
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := pageTemplate.Execute(w, data); err != nil {
+			log.Errorf("Error rendering deliveries page: %v", err)
+		}
+	})
+}
+
+type pageData struct {
+	Alertname string
+	Topic     string
+	Since     string
+	Error     string
+	Records   []Record
+}
+
+var pageTemplate = template.Must(template.New("deliveries").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04:05 MST") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Deliveries</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.failed { background: #fdd; }
+pre { white-space: pre-wrap; margin: 0; }
+</style>
+</head>
+<body>
+<h1>Recent deliveries</h1>
+<form method="get">
+<label>Alertname <input name="alertname" value="{{.Alertname}}"></label>
+<label>Topic <input name="topic" value="{{.Topic}}"></label>
+<label>Since <input name="since" value="{{.Since}}" placeholder="2h or 2024-01-01T03:00:00Z"></label>
+<button type="submit">Filter</button>
+</form>
+{{if .Error}}<p class="failed">{{.Error}}</p>{{end}}
+<table>
+<tr><th>Time</th><th>Topic</th><th>Status</th><th>MessageId / error</th><th>Alerts</th><th>Message</th></tr>
+{{range .Records}}
+<tr{{if eq .Status "failed"}} class="failed"{{end}}>
+<td>{{formatTime .Time}}</td>
+<td>{{.Topic}}</td>
+<td>{{.Status}}</td>
+<td>{{if .Error}}{{.Error}}{{else}}{{.MessageID}}{{if .SequenceNumber}} (seq {{.SequenceNumber}}){{end}}{{end}}</td>
+<td>{{range .Alerts}}{{.Alertname}} [{{.Status}}]<br>{{end}}</td>
+<td><details><summary>show</summary><pre>{{.Message}}</pre></details></td>
+</tr>
+{{else}}
+<tr><td colspan="6">No deliveries</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
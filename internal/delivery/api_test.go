@@ -0,0 +1,104 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/internal/web"
+)
+
+func TestHistoryFind(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	h, err := NewHistory(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Add(Record{Topic: "oncall", TopicARN: "arn:oncall", Time: now.Add(-3 * time.Hour), Alerts: []AlertRef{{Alertname: "HighLoad"}}})
+	h.Add(Record{Topic: "team", TopicARN: "arn:team", Time: now.Add(-2 * time.Hour), Alerts: []AlertRef{{Alertname: "DiskFull"}}})
+	h.Add(Record{Topic: "oncall", TopicARN: "arn:oncall", Time: now.Add(-time.Hour), Alerts: []AlertRef{{Alertname: "DiskFull"}, {Alertname: "HighLoad"}}})
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []time.Time
+	}{
+		{"all", Query{}, []time.Time{now.Add(-time.Hour), now.Add(-2 * time.Hour), now.Add(-3 * time.Hour)}},
+		{"alertname", Query{Alertname: "HighLoad"}, []time.Time{now.Add(-time.Hour), now.Add(-3 * time.Hour)}},
+		{"topic name", Query{Topic: "team"}, []time.Time{now.Add(-2 * time.Hour)}},
+		{"topic ARN", Query{Topic: "arn:oncall"}, []time.Time{now.Add(-time.Hour), now.Add(-3 * time.Hour)}},
+		{"since", Query{Since: now.Add(-2 * time.Hour)}, []time.Time{now.Add(-time.Hour), now.Add(-2 * time.Hour)}},
+		{"limit", Query{Limit: 1}, []time.Time{now.Add(-time.Hour)}},
+		{"no match", Query{Alertname: "Unknown"}, []time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []time.Time{}
+			for _, record := range h.Find(tt.query) {
+				got = append(got, record.Time)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query   string
+		want    Query
+		wantErr bool
+	}{
+		{"", Query{}, false},
+		{"alertname=HighLoad&topic=oncall&limit=5", Query{Alertname: "HighLoad", Topic: "oncall", Limit: 5}, false},
+		{"since=2h", Query{Since: now.Add(-2 * time.Hour)}, false},
+		{"since=2024-05-01T10:00:00Z", Query{Since: now.Add(-2 * time.Hour)}, false},
+		{"since=yesterday", Query{}, true},
+		{"limit=-1", Query{}, true},
+		{"limit=ten", Query{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/deliveries?"+tt.query, nil)
+			got, err := parseQuery(r, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliveriesAPIError(t *testing.T) {
+	h, err := NewHistory(10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, h)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/deliveries?limit=ten", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	var body web.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Status != "error" || body.Error != `invalid limit "ten"` {
+		t.Errorf("body = %+v, want the shared error response", body)
+	}
+}
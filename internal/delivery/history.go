@@ -0,0 +1,200 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/internal/atomicfile"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	StatusSent   = "sent"
+	StatusFailed = "failed"
+)
+
+// maxMessagePreview is the number of bytes of a message kept in a record.
+// Messages can be up to 256 KiB, which would make the history hold and
+// persist hundreds of megabytes.
+const maxMessagePreview = 4096
+
+// AlertRef identifies an alert included in a delivered message.
+type AlertRef struct {
+	Alertname   string            `json:"alertname"`
+	Fingerprint string            `json:"fingerprint"`
+	Status      string            `json:"status"`
+	StartsAt    string            `json:"startsAt"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// Record describes a single Publish call to an SNS topic. Only the first
+// maxMessagePreview bytes of the message are kept.
+type Record struct {
+	Time             time.Time  `json:"time"`
+	Topic            string     `json:"topic"`
+	TopicARN         string     `json:"topicArn"`
+	Status           string     `json:"status"`
+	MessageID        string     `json:"messageId,omitempty"`
+	SequenceNumber   string     `json:"sequenceNumber,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	Alerts           []AlertRef `json:"alerts"`
+	Message          string     `json:"message"`
+	MessageTruncated bool       `json:"messageTruncated,omitempty"`
+}
+
+// HasAlert reports whether the message included an alert with the given
+// alertname.
+func (r Record) HasAlert(alertname string) bool {
+	for _, alert := range r.Alerts {
+		if alert.Alertname == alertname {
+			return true
+		}
+	}
+	return false
+}
+
+// History keeps the most recent delivery records in a ring buffer. If a
+// file path is given, the records are persisted so that they survive
+// restarts.
+type History struct {
+	path string
+
+	mu      sync.RWMutex
+	records []Record
+	next    int
+	full    bool
+	dirty   bool
+}
+
+// NewHistory creates a history holding up to size records and loads the
+// records saved in path. An empty path yields an in-memory history.
+func NewHistory(size int, path string) (*History, error) {
+	h := &History{
+		path:    path,
+		records: make([]Record, size),
+	}
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delivery history '%s': %v", path, err)
+	}
+
+	var records []Record
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("failed to parse delivery history '%s': %v", path, err)
+		}
+	}
+	// The file is ordered newest first.
+	for i := len(records) - 1; i >= 0; i-- {
+		h.add(records[i])
+	}
+	h.dirty = false
+
+	log.Infof("Loaded %d records from delivery history %s", len(records), path)
+	return h, nil
+}
+
+// Add stores a record, replacing the oldest one if the history is full.
+func (h *History) Add(record Record) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.add(record)
+}
+
+func (h *History) add(record Record) {
+	if len(h.records) == 0 {
+		return
+	}
+	if len(record.Message) > maxMessagePreview {
+		record.Message = strings.ToValidUTF8(record.Message[:maxMessagePreview], "")
+		record.MessageTruncated = true
+	}
+	h.dirty = true
+	h.records[h.next] = record
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// List returns the stored records, newest first.
+func (h *History) List() []Record {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.list()
+}
+
+func (h *History) list() []Record {
+	count := h.next
+	if h.full {
+		count = len(h.records)
+	}
+
+	result := make([]Record, 0, count)
+	for i := 1; i <= count; i++ {
+		result = append(result, h.records[(h.next-i+len(h.records))%len(h.records)])
+	}
+	return result
+}
+
+// Run periodically saves the history until ctx is cancelled.
+func (h *History) Run(ctx context.Context, interval time.Duration) {
+	if h.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.Save(); err != nil {
+				log.Errorf("Error saving delivery history: %v", err)
+			}
+		}
+	}
+}
+
+// Save persists the history if it changed since it was last saved.
+func (h *History) Save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(h.list())
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery history: %v", err)
+	}
+
+	if err := atomicfile.Write(h.path, data); err != nil {
+		return fmt.Errorf("failed to write delivery history: %v", err)
+	}
+
+	h.dirty = false
+	return nil
+}
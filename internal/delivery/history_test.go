@@ -0,0 +1,116 @@
+package delivery
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func topics(records []Record) []string {
+	names := make([]string, 0, len(records))
+	for _, record := range records {
+		names = append(names, record.Topic)
+	}
+	return names
+}
+
+func TestHistoryRingBuffer(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		added []string
+		want  []string
+	}{
+		{"empty", 3, nil, []string{}},
+		{"partially filled", 3, []string{"a", "b"}, []string{"b", "a"}},
+		{"exactly full", 3, []string{"a", "b", "c"}, []string{"c", "b", "a"}},
+		{"wrapped", 3, []string{"a", "b", "c", "d", "e"}, []string{"e", "d", "c"}},
+		{"wrapped twice", 2, []string{"a", "b", "c", "d", "e"}, []string{"e", "d"}},
+		{"zero size keeps nothing", 0, []string{"a"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHistory(tt.size, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, topic := range tt.added {
+				h.Add(Record{Topic: topic})
+			}
+			if got := topics(h.List()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	h, err := NewHistory(3, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, topic := range []string{"a", "b", "c", "d"} {
+		h.Add(Record{Topic: topic, Time: time.Now()})
+	}
+	if err := h.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if h.dirty {
+		t.Error("history is still dirty after saving")
+	}
+
+	// A smaller history keeps only the newest saved records.
+	loaded, err := NewHistory(2, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := topics(loaded.List()), []string{"d", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded List() = %v, want %v", got, want)
+	}
+	if loaded.dirty {
+		t.Error("freshly loaded history is dirty")
+	}
+
+	loaded.Add(Record{Topic: "e"})
+	if got, want := topics(loaded.List()), []string{"e", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() after Add = %v, want %v", got, want)
+	}
+}
+
+func TestHistoryKeepsMessagePreview(t *testing.T) {
+	tests := []struct {
+		name          string
+		message       string
+		wantLen       int
+		wantTruncated bool
+	}{
+		{"short", "alert", 5, false},
+		{"at limit", strings.Repeat("a", maxMessagePreview), maxMessagePreview, false},
+		{"long", strings.Repeat("a", 256*1024), maxMessagePreview, true},
+		{"cut inside rune", strings.Repeat("a", maxMessagePreview-1) + "ü", maxMessagePreview - 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewHistory(1, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.Add(Record{Message: tt.message})
+
+			got := h.List()[0]
+			if len(got.Message) != tt.wantLen || got.MessageTruncated != tt.wantTruncated {
+				t.Errorf("message length = %d, truncated = %v, want %d, %v", len(got.Message), got.MessageTruncated, tt.wantLen, tt.wantTruncated)
+			}
+			if !utf8.ValidString(got.Message) {
+				t.Error("preview is not valid UTF-8")
+			}
+		})
+	}
+}
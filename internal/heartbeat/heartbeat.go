@@ -0,0 +1,177 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/aws"
+	"github.com/maks3201/sns-alert-service/internal/matchers"
+	log "github.com/sirupsen/logrus"
+)
+
+// retryInterval is the delay before a failed heartbeat message is re-sent.
+const retryInterval = 30 * time.Second
+
+// Monitor implements a dead man's switch: alerts matching the heartbeat
+// matchers, such as Alertmanager's always-firing Watchdog alert, are not
+// forwarded but reset a timer. If no heartbeat arrives within the timeout,
+// the monitor publishes a message to the configured topic.
+type Monitor struct {
+	cfg       config.HeartbeatConfig
+	matchers  matchers.Matchers
+	topic     config.SNSTopicConfig
+	awsClient aws.SNSClient
+	timeout   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	lastBeat time.Time
+	missing  bool
+	lastSent time.Time
+	retryAt  time.Time
+}
+
+// New creates a monitor from the configuration. Without heartbeat matchers
+// the monitor is disabled: it matches no alerts and never publishes.
+func New(cfg config.Config, awsClient aws.SNSClient) (*Monitor, error) {
+	return newMonitor(cfg, awsClient, time.Now)
+}
+
+// newMonitor creates a monitor that reads the current time from now. The
+// timeout of the first heartbeat starts when the monitor is created.
+func newMonitor(cfg config.Config, awsClient aws.SNSClient, now func() time.Time) (*Monitor, error) {
+	m := &Monitor{
+		cfg:       cfg.Heartbeat,
+		awsClient: awsClient,
+		timeout:   time.Duration(cfg.Heartbeat.TimeoutSeconds) * time.Second,
+		now:       now,
+		lastBeat:  now(),
+	}
+	if len(cfg.Heartbeat.Matchers) == 0 {
+		return m, nil
+	}
+
+	ms, err := matchers.ParseAll(cfg.Heartbeat.Matchers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid heartbeat matchers: %v", err)
+	}
+	m.matchers = ms
+
+	found := false
+	for _, topic := range cfg.Topics {
+		if topic.Name == cfg.Heartbeat.Topic {
+			m.topic = topic
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("heartbeat topic '%s' is not configured in topics", cfg.Heartbeat.Topic)
+	}
+
+	return m, nil
+}
+
+// Enabled reports whether heartbeat matchers are configured.
+func (m *Monitor) Enabled() bool {
+	return len(m.matchers) > 0
+}
+
+// Matches reports whether the alert is a heartbeat.
+func (m *Monitor) Matches(labels map[string]string) bool {
+	return m.Enabled() && m.matchers.Matches(labels)
+}
+
+// Beat records a received heartbeat.
+func (m *Monitor) Beat(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastBeat = now
+	HeartbeatLastReceived.Set(float64(now.Unix()))
+	log.Debugf("Heartbeat received at %s", now.Format(time.RFC3339))
+}
+
+// Run checks for missing heartbeats until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	if !m.Enabled() {
+		return
+	}
+
+	log.Infof("Heartbeat monitor started, expecting %v every %s", m.matchers, m.timeout)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx, m.now())
+		}
+	}
+}
+
+func (m *Monitor) check(ctx context.Context, now time.Time) {
+	m.mu.Lock()
+	lastBeat := m.lastBeat
+	missing := now.Sub(lastBeat) > m.timeout
+	wasMissing := m.missing
+	repeat := time.Duration(m.cfg.RepeatIntervalSeconds) * time.Second
+	due := (m.lastSent.IsZero() || (repeat > 0 && now.Sub(m.lastSent) >= repeat)) && !now.Before(m.retryAt)
+	m.missing = missing
+	m.mu.Unlock()
+
+	switch {
+	case missing:
+		HeartbeatMissing.Set(1)
+		if !wasMissing {
+			log.Errorf("No heartbeat received for %s, alerting pipeline may be down", now.Sub(lastBeat).Round(time.Second))
+		}
+		if !due {
+			return
+		}
+
+		message := fmt.Sprintf("%s\nNo heartbeat received since %s (%s ago).",
+			m.cfg.Message, lastBeat.UTC().Format(time.RFC3339), now.Sub(lastBeat).Round(time.Second))
+		sent := m.publish(ctx, message)
+
+		m.mu.Lock()
+		if sent {
+			m.lastSent = now
+		} else {
+			m.retryAt = now.Add(retryInterval)
+		}
+		m.mu.Unlock()
+	case wasMissing:
+		HeartbeatMissing.Set(0)
+		log.Infof("Heartbeat received again at %s", lastBeat.Format(time.RFC3339))
+
+		m.mu.Lock()
+		notified := !m.lastSent.IsZero()
+		m.lastSent = time.Time{}
+		m.retryAt = time.Time{}
+		m.mu.Unlock()
+
+		if notified && m.cfg.SendResolved {
+			m.publish(ctx, fmt.Sprintf("%s\nHeartbeat received again at %s.", m.cfg.ResolvedMessage, lastBeat.UTC().Format(time.RFC3339)))
+		}
+	}
+}
+
+func (m *Monitor) publish(ctx context.Context, message string) bool {
+	result, err := m.awsClient.PublishToSNS(ctx, m.topic.ARN, message)
+	if err != nil {
+		log.Errorf("Error sending heartbeat message to SNS topic %s: %v", m.topic.Name, err)
+		return false
+	}
+	log.WithFields(log.Fields{
+		"topic":      m.topic.Name,
+		"message_id": result.MessageID,
+	}).Infof("Heartbeat message sent to SNS topic: %s", m.topic.ARN)
+	return true
+}
@@ -0,0 +1,178 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/aws"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeSNSClient struct {
+	attempts []string
+	err      error
+}
+
+func (c *fakeSNSClient) PublishToSNS(ctx context.Context, topicArn string, message string) (aws.PublishResult, error) {
+	c.attempts = append(c.attempts, message)
+	return aws.PublishResult{MessageID: "test"}, c.err
+}
+
+func (c *fakeSNSClient) CheckSNSConnection(ctx context.Context) error {
+	return nil
+}
+
+func testConfig(sendResolved bool) config.Config {
+	return config.Config{
+		Topics: []config.SNSTopicConfig{{Name: "ops", ARN: "arn:aws:sns:eu-central-1:123456789012:ops"}},
+		Heartbeat: config.HeartbeatConfig{
+			Matchers:              []string{`alertname="Watchdog"`},
+			TimeoutSeconds:        60,
+			Topic:                 "ops",
+			Message:               "Alerting pipeline is down",
+			RepeatIntervalSeconds: 600,
+			SendResolved:          sendResolved,
+			ResolvedMessage:       "Alerting pipeline recovered",
+		},
+	}
+}
+
+func TestNewDoesNotReportAHeartbeat(t *testing.T) {
+	HeartbeatLastReceived.Set(0)
+	if _, err := New(testConfig(false), &fakeSNSClient{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(HeartbeatLastReceived); got != 0 {
+		t.Errorf("last received = %v before the first heartbeat, want 0", got)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	m, err := New(testConfig(false), &fakeSNSClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{map[string]string{"alertname": "Watchdog"}, true},
+		{map[string]string{"alertname": "HighLoad"}, false},
+		{map[string]string{}, false},
+	}
+	for _, tt := range tests {
+		if got := m.Matches(tt.labels); got != tt.want {
+			t.Errorf("Matches(%v) = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	type step struct {
+		at   time.Duration
+		beat bool // a heartbeat arrives at this time instead of a check
+		fail bool // publishing fails
+		want string
+	}
+	tests := []struct {
+		name         string
+		sendResolved bool
+		steps        []step
+	}{
+		{
+			name: "missing heartbeat alerts once",
+			steps: []step{
+				{at: 30 * time.Second, want: ""},
+				{at: 61 * time.Second, want: "down"},
+				{at: 120 * time.Second, want: ""},
+			},
+		},
+		{
+			name: "repeats after repeat interval",
+			steps: []step{
+				{at: 61 * time.Second, want: "down"},
+				{at: 660 * time.Second, want: ""},
+				{at: 661 * time.Second, want: "down"},
+			},
+		},
+		{
+			name: "retries failed publish",
+			steps: []step{
+				{at: 61 * time.Second, fail: true, want: "down"},
+				{at: 70 * time.Second, want: ""},
+				{at: 91 * time.Second, want: "down"},
+				{at: 100 * time.Second, want: ""},
+			},
+		},
+		{
+			name:         "sends resolved",
+			sendResolved: true,
+			steps: []step{
+				{at: 61 * time.Second, want: "down"},
+				{at: 100 * time.Second, beat: true},
+				{at: 101 * time.Second, want: "recovered"},
+				{at: 102 * time.Second, want: ""},
+			},
+		},
+		{
+			name: "resolved not sent by default",
+			steps: []step{
+				{at: 61 * time.Second, want: "down"},
+				{at: 100 * time.Second, beat: true},
+				{at: 101 * time.Second, want: ""},
+			},
+		},
+		{
+			name:         "resolved not sent without notification",
+			sendResolved: true,
+			steps: []step{
+				{at: 61 * time.Second, fail: true, want: "down"},
+				{at: 70 * time.Second, beat: true},
+				{at: 71 * time.Second, want: ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := start
+			client := &fakeSNSClient{}
+			m, err := newMonitor(testConfig(tt.sendResolved), client, func() time.Time { return clock })
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range tt.steps {
+				clock = start.Add(s.at)
+				if s.beat {
+					m.Beat(clock)
+					continue
+				}
+
+				client.err = nil
+				if s.fail {
+					client.err = errors.New("throttled")
+				}
+				before := len(client.attempts)
+				m.check(context.Background(), m.now())
+
+				got := ""
+				if len(client.attempts) > before {
+					got = "down"
+					if strings.HasPrefix(client.attempts[len(client.attempts)-1], "Alerting pipeline recovered") {
+						got = "recovered"
+					}
+				}
+				if got != s.want {
+					t.Errorf("at %s: published %q, want %q", s.at, got, s.want)
+				}
+			}
+		})
+	}
+}
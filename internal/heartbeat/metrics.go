@@ -0,0 +1,26 @@
+package heartbeat
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	HeartbeatLastReceived = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sns_heartbeat_last_received_timestamp_seconds",
+			Help: "Unix time of the last received heartbeat alert",
+		},
+	)
+
+	HeartbeatMissing = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sns_heartbeat_missing",
+			Help: "Whether the heartbeat timeout has expired (1) or not (0)",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(HeartbeatLastReceived)
+	prometheus.MustRegister(HeartbeatMissing)
+}
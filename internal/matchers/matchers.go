@@ -0,0 +1,145 @@
+package matchers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Type is the kind of comparison a Matcher performs.
+type Type int
+
+const (
+	MatchEqual Type = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+func (t Type) String() string {
+	switch t {
+	case MatchNotEqual:
+		return "!="
+	case MatchRegexp:
+		return "=~"
+	case MatchNotRegexp:
+		return "!~"
+	default:
+		return "="
+	}
+}
+
+// Matcher compares the value of a single label against a string or a
+// regular expression, using the same syntax as Alertmanager matchers, e.g.
+// severity=~"critical|page" or env!="dev".
+type Matcher struct {
+	Name  string
+	Type  Type
+	Value string
+	re    *regexp.Regexp
+}
+
+var matcherRegexp = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+
+// New creates a matcher. Regular expressions are anchored at both ends.
+func New(t Type, name, value string) (*Matcher, error) {
+	m := &Matcher{Name: name, Type: t, Value: value}
+	if t == MatchRegexp || t == MatchNotRegexp {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", value, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Parse parses a matcher of the form name<op>"value". The quotes around the
+// value are optional.
+func Parse(s string) (*Matcher, error) {
+	parts := matcherRegexp.FindStringSubmatch(s)
+	if parts == nil {
+		return nil, fmt.Errorf("invalid matcher %q", s)
+	}
+
+	value := parts[3]
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher value in %q: %v", s, err)
+		}
+		value = unquoted
+	}
+
+	var t Type
+	switch parts[2] {
+	case "!=":
+		t = MatchNotEqual
+	case "=~":
+		t = MatchRegexp
+	case "!~":
+		t = MatchNotRegexp
+	default:
+		t = MatchEqual
+	}
+
+	m, err := New(t, parts[1], value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid matcher %q: %v", s, err)
+	}
+	return m, nil
+}
+
+// Matches reports whether the value satisfies the matcher.
+func (m *Matcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	default:
+		return value == m.Value
+	}
+}
+
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// Matchers is a list of matchers that all have to match.
+type Matchers []*Matcher
+
+// ParseAll parses a list of matcher strings.
+func ParseAll(ss []string) (Matchers, error) {
+	ms := make(Matchers, 0, len(ss))
+	for _, s := range ss {
+		m, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// Matches reports whether all matchers match the label set. A missing label
+// is treated as an empty value.
+func (ms Matchers) Matches(labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ms Matchers) String() string {
+	parts := make([]string, 0, len(ms))
+	for _, m := range ms {
+		parts = append(parts, m.String())
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
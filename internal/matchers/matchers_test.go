@@ -0,0 +1,110 @@
+package matchers
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantName  string
+		wantType  Type
+		wantValue string
+		wantErr   bool
+	}{
+		{`severity="critical"`, "severity", MatchEqual, "critical", false},
+		{`severity=critical`, "severity", MatchEqual, "critical", false},
+		{` env != "dev" `, "env", MatchNotEqual, "dev", false},
+		{`severity=~"critical|page"`, "severity", MatchRegexp, "critical|page", false},
+		{`team!~"db.*"`, "team", MatchNotRegexp, "db.*", false},
+		{`summary="say \"hi\""`, "summary", MatchEqual, `say "hi"`, false},
+		{`empty=""`, "empty", MatchEqual, "", false},
+		{`1name="x"`, "", 0, "", true},
+		{`severity`, "", 0, "", true},
+		{`severity="unterminated`, "", 0, "", true},
+		{`severity=~"("`, "", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m.Name != tt.wantName || m.Type != tt.wantType || m.Value != tt.wantValue {
+				t.Errorf("Parse() = %s %s %q, want %s %s %q", m.Name, m.Type, m.Value, tt.wantName, tt.wantType, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestMatcherMatches(t *testing.T) {
+	tests := []struct {
+		matcher string
+		value   string
+		want    bool
+	}{
+		{`severity="critical"`, "critical", true},
+		{`severity="critical"`, "warning", false},
+		{`severity!="critical"`, "warning", true},
+		{`severity!="critical"`, "critical", false},
+		{`severity=~"critical|page"`, "page", true},
+		{`severity=~"crit"`, "critical", false},
+		{`severity!~"crit.*"`, "critical", false},
+		{`severity!~"crit.*"`, "warning", true},
+		{`severity=""`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.matcher+"/"+tt.value, func(t *testing.T) {
+			m, err := Parse(tt.matcher)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Matches(tt.value); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchersMatches(t *testing.T) {
+	labels := map[string]string{"alertname": "HighLoad", "severity": "critical", "env": "prod"}
+
+	tests := []struct {
+		name     string
+		matchers []string
+		want     bool
+	}{
+		{"no matchers", nil, true},
+		{"all match", []string{`alertname="HighLoad"`, `env=~"prod|staging"`}, true},
+		{"one does not match", []string{`alertname="HighLoad"`, `env="dev"`}, false},
+		{"missing label is empty", []string{`team=""`}, true},
+		{"missing label does not equal a value", []string{`team="db"`}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms, err := ParseAll(tt.matchers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ms.Matches(labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchersString(t *testing.T) {
+	ms, err := ParseAll([]string{`alertname="HighLoad"`, `severity=~critical|page`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ms.String(), `{alertname="HighLoad",severity=~"critical|page"}`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
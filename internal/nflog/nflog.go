@@ -0,0 +1,100 @@
+package nflog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/internal/atomicfile"
+	log "github.com/sirupsen/logrus"
+)
+
+// Log remembers which notifications have been delivered and until when
+// repeated copies of them should be suppressed. If a file path is given,
+// the log is persisted so that it survives restarts.
+type Log struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]time.Time
+}
+
+// Open loads the notification log from path. An empty path yields an
+// in-memory log; a missing file yields an empty log.
+func Open(path string) (*Log, error) {
+	l := &Log{
+		path:    path,
+		entries: make(map[string]time.Time),
+	}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification log '%s': %v", path, err)
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &l.entries); err != nil {
+			return nil, fmt.Errorf("failed to parse notification log '%s': %v", path, err)
+		}
+	}
+	l.gc(time.Now())
+
+	log.Infof("Loaded %d entries from notification log %s", len(l.entries), path)
+	return l, nil
+}
+
+// Seen reports whether a notification with the given key has been
+// delivered and has not expired yet.
+func (l *Log) Seen(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt, ok := l.entries[key]
+	return ok && now.Before(expiresAt)
+}
+
+// Record marks the notifications with the given keys as delivered until
+// expiresAt and persists the log.
+func (l *Log) Record(keys []string, expiresAt time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		l.entries[key] = expiresAt
+	}
+	l.gc(time.Now())
+
+	return l.save()
+}
+
+func (l *Log) gc(now time.Time) {
+	for key, expiresAt := range l.entries {
+		if !now.Before(expiresAt) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+func (l *Log) save() error {
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(l.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification log: %v", err)
+	}
+
+	if err := atomicfile.Write(l.path, data); err != nil {
+		return fmt.Errorf("failed to write notification log: %v", err)
+	}
+	return nil
+}
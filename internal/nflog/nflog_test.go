@@ -0,0 +1,98 @@
+package nflog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogSeen(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		key  string
+		at   time.Time
+		want bool
+	}{
+		{"recorded key", "a", now, true},
+		{"just before expiry", "a", now.Add(time.Hour - time.Second), true},
+		{"at expiry", "a", now.Add(time.Hour), false},
+		{"unknown key", "b", now, false},
+	}
+
+	l, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record([]string{"a"}, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.Seen(tt.key, tt.at); got != tt.want {
+				t.Errorf("Seen(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nflog.json")
+	now := time.Now()
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record([]string{"active"}, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Record([]string{"expired"}, now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.Seen("active", now) {
+		t.Error("active entry was not persisted")
+	}
+	if _, ok := reopened.entries["expired"]; ok {
+		t.Error("expired entry was persisted")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *string
+		wantErr bool
+	}{
+		{"missing file", nil, false},
+		{"empty file", ptr(""), false},
+		{"valid file", ptr(`{"a":"2999-01-01T00:00:00Z"}`), false},
+		{"corrupted file", ptr(`{"a":`), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "nflog.json")
+			if tt.content != nil {
+				if err := os.WriteFile(path, []byte(*tt.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := Open(path); (err != nil) != tt.wantErr {
+				t.Errorf("Open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,35 @@
+package selfmon
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	DeliveryFailureRatio = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sns_delivery_failure_ratio",
+			Help: "Ratio of failed SNS publishes within the meta alert window",
+		},
+	)
+
+	MetaAlertFiring = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sns_meta_alert_firing",
+			Help: "Whether the meta alert about failing SNS deliveries is firing (1) or not (0)",
+		},
+	)
+
+	MetaAlertNotifications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sns_meta_alert_notifications_total",
+			Help: "Total number of meta alert notifications by channel and result",
+		},
+		[]string{"channel", "result"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(DeliveryFailureRatio)
+	prometheus.MustRegister(MetaAlertFiring)
+	prometheus.MustRegister(MetaAlertNotifications)
+}
@@ -0,0 +1,261 @@
+package selfmon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/aws"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// alertname identifies the meta alert in messages and webhook payloads.
+	alertname = "SNSDeliveryFailing"
+
+	evaluationInterval = 10 * time.Second
+)
+
+// Monitor raises a meta alert when the ratio of failed SNS publishes over a
+// sliding window exceeds a threshold. The alert is delivered through a
+// channel independent of the monitored topics: a topic in another region
+// and/or a webhook. Notifications are rate limited by a minimum interval.
+type Monitor struct {
+	cfg         config.MetaAlertConfig
+	window      time.Duration
+	minInterval time.Duration
+	snsClient   aws.SNSClient
+	httpClient  *http.Client
+
+	mu       sync.Mutex
+	buckets  []bucket
+	firing   bool
+	lastSent time.Time
+}
+
+// bucket counts the publishes of one second of the window.
+type bucket struct {
+	second   int64
+	attempts int
+	failures int
+}
+
+// WebhookPayload is the JSON body posted to the webhook.
+type WebhookPayload struct {
+	Status        string    `json:"status"`
+	Alertname     string    `json:"alertname"`
+	Summary       string    `json:"summary"`
+	FailureRatio  float64   `json:"failureRatio"`
+	Attempts      int       `json:"attempts"`
+	Failures      int       `json:"failures"`
+	WindowSeconds int       `json:"windowSeconds"`
+	Time          time.Time `json:"time"`
+}
+
+// New creates a monitor from the configuration. Without a topic or webhook
+// to deliver the meta alert to, the monitor is disabled.
+func New(cfg config.Config) (*Monitor, error) {
+	metaCfg := cfg.MetaAlert
+	m := &Monitor{
+		cfg:         metaCfg,
+		window:      time.Duration(metaCfg.WindowSeconds) * time.Second,
+		minInterval: time.Duration(metaCfg.MinIntervalSeconds) * time.Second,
+		httpClient:  &http.Client{Timeout: time.Duration(cfg.Timeouts.AWS.APICallTimeoutSeconds) * time.Second},
+		buckets:     make([]bucket, metaCfg.WindowSeconds),
+	}
+
+	if metaCfg.SNS.TopicARN != "" {
+		client, err := aws.NewClientForRegion(cfg, metaCfg.SNS.Region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SNS client for meta alerts: %v", err)
+		}
+		m.snsClient = client
+	}
+	return m, nil
+}
+
+// Enabled reports whether a channel for the meta alert is configured.
+func (m *Monitor) Enabled() bool {
+	return m.cfg.SNS.TopicARN != "" || m.cfg.WebhookURL != ""
+}
+
+// Wrap returns an SNS client that records the outcome of every publish
+// made through client.
+func (m *Monitor) Wrap(client aws.SNSClient) aws.SNSClient {
+	if !m.Enabled() {
+		return client
+	}
+	return &observedClient{SNSClient: client, monitor: m}
+}
+
+type observedClient struct {
+	aws.SNSClient
+	monitor *Monitor
+}
+
+func (c *observedClient) PublishToSNS(ctx context.Context, topicArn string, message string) (aws.PublishResult, error) {
+	result, err := c.SNSClient.PublishToSNS(ctx, topicArn, message)
+	c.monitor.record(time.Now(), err != nil)
+	return result, err
+}
+
+func (m *Monitor) record(now time.Time, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	second := now.Unix()
+	b := &m.buckets[second%int64(len(m.buckets))]
+	if b.second != second {
+		*b = bucket{second: second}
+	}
+	b.attempts++
+	if failed {
+		b.failures++
+	}
+}
+
+// stats returns the number of publishes and failures within the window.
+func (m *Monitor) stats(now time.Time) (int, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts, failures := 0, 0
+	oldest := now.Unix() - int64(len(m.buckets))
+	for _, b := range m.buckets {
+		if b.second > oldest {
+			attempts += b.attempts
+			failures += b.failures
+		}
+	}
+	return attempts, failures
+}
+
+// Run evaluates the failure ratio periodically until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	if !m.Enabled() {
+		return
+	}
+
+	log.Infof("Meta alert enabled: firing when more than %.0f%% of publishes fail within %s", m.cfg.FailureRatio*100, m.window)
+
+	ticker := time.NewTicker(evaluationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.evaluate(ctx, now)
+		}
+	}
+}
+
+func (m *Monitor) evaluate(ctx context.Context, now time.Time) {
+	attempts, failures := m.stats(now)
+	ratio := 0.0
+	if attempts > 0 {
+		ratio = float64(failures) / float64(attempts)
+	}
+	DeliveryFailureRatio.Set(ratio)
+
+	failing := attempts >= m.cfg.MinAttempts && ratio >= m.cfg.FailureRatio
+
+	m.mu.Lock()
+	wasFiring := m.firing
+	due := now.Sub(m.lastSent) >= m.minInterval
+	m.firing = failing
+	m.mu.Unlock()
+
+	payload := WebhookPayload{
+		Alertname:     alertname,
+		FailureRatio:  ratio,
+		Attempts:      attempts,
+		Failures:      failures,
+		WindowSeconds: m.cfg.WindowSeconds,
+		Time:          now.UTC(),
+	}
+
+	switch {
+	case failing:
+		MetaAlertFiring.Set(1)
+		if !wasFiring {
+			log.Errorf("SNS delivery is failing: %d of %d publishes failed within %s", failures, attempts, m.window)
+		}
+		if !due {
+			return
+		}
+		payload.Status = "firing"
+		payload.Summary = fmt.Sprintf("[FIRING] %s: %d of %d SNS publishes (%.0f%%) failed in the last %s",
+			alertname, failures, attempts, ratio*100, m.window)
+	case wasFiring:
+		MetaAlertFiring.Set(0)
+		log.Infof("SNS delivery recovered: %d of %d publishes failed within %s", failures, attempts, m.window)
+		if !m.cfg.SendResolved {
+			return
+		}
+		payload.Status = "resolved"
+		payload.Summary = fmt.Sprintf("[RESOLVED] %s: %d of %d SNS publishes failed in the last %s",
+			alertname, failures, attempts, m.window)
+	default:
+		return
+	}
+
+	m.notify(ctx, payload)
+
+	m.mu.Lock()
+	m.lastSent = now
+	m.mu.Unlock()
+}
+
+func (m *Monitor) notify(ctx context.Context, payload WebhookPayload) {
+	if m.snsClient != nil {
+		result, err := m.snsClient.PublishToSNS(ctx, m.cfg.SNS.TopicARN, payload.Summary)
+		if err != nil {
+			log.Errorf("Error sending meta alert to SNS topic %s: %v", m.cfg.SNS.TopicARN, err)
+			MetaAlertNotifications.WithLabelValues("sns", "failed").Inc()
+		} else {
+			log.WithField("message_id", result.MessageID).Infof("Meta alert sent to SNS topic: %s", m.cfg.SNS.TopicARN)
+			MetaAlertNotifications.WithLabelValues("sns", "sent").Inc()
+		}
+	}
+
+	if m.cfg.WebhookURL != "" {
+		if err := m.postWebhook(ctx, payload); err != nil {
+			log.Errorf("Error sending meta alert to webhook: %v", err)
+			MetaAlertNotifications.WithLabelValues("webhook", "failed").Inc()
+		} else {
+			log.Infof("Meta alert sent to webhook %s", m.cfg.WebhookURL)
+			MetaAlertNotifications.WithLabelValues("webhook", "sent").Inc()
+		}
+	}
+}
+
+func (m *Monitor) postWebhook(ctx context.Context, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package selfmon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"github.com/maks3201/sns-alert-service/internal/aws"
+)
+
+type fakeSNSClient struct {
+	err error
+}
+
+func (c *fakeSNSClient) PublishToSNS(ctx context.Context, topicArn string, message string) (aws.PublishResult, error) {
+	return aws.PublishResult{MessageID: "test"}, c.err
+}
+
+func (c *fakeSNSClient) CheckSNSConnection(ctx context.Context) error {
+	return nil
+}
+
+func newTestMonitor(t *testing.T, metaCfg config.MetaAlertConfig) *Monitor {
+	t.Helper()
+	var cfg config.Config
+	cfg.Timeouts.AWS.APICallTimeoutSeconds = 5
+	cfg.MetaAlert = metaCfg
+	m, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestStats(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+
+	type record struct {
+		offset time.Duration
+		failed bool
+	}
+	tests := []struct {
+		name         string
+		records      []record
+		at           time.Duration
+		wantAttempts int
+		wantFailures int
+	}{
+		{
+			name: "empty window",
+			at:   0,
+		},
+		{
+			name: "counts within window",
+			records: []record{
+				{0, false}, {0, true}, {time.Second, true}, {9 * time.Second, false},
+			},
+			at:           9 * time.Second,
+			wantAttempts: 4,
+			wantFailures: 2,
+		},
+		{
+			name: "drops buckets older than window",
+			records: []record{
+				{0, true}, {5 * time.Second, false},
+			},
+			at:           10 * time.Second,
+			wantAttempts: 1,
+			wantFailures: 0,
+		},
+		{
+			name: "reused bucket is reset",
+			records: []record{
+				{0, true}, {0, true}, {10 * time.Second, false},
+			},
+			at:           10 * time.Second,
+			wantAttempts: 1,
+			wantFailures: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor(t, config.MetaAlertConfig{WindowSeconds: 10})
+			for _, r := range tt.records {
+				m.record(start.Add(r.offset), r.failed)
+			}
+			attempts, failures := m.stats(start.Add(tt.at))
+			if attempts != tt.wantAttempts || failures != tt.wantFailures {
+				t.Errorf("stats = %d/%d, want %d/%d", failures, attempts, tt.wantFailures, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+
+	type step struct {
+		attempts int
+		failures int
+		offset   time.Duration
+		want     string // status posted to the webhook, empty for none
+	}
+	tests := []struct {
+		name         string
+		sendResolved bool
+		steps        []step
+	}{
+		{
+			name:  "below min attempts",
+			steps: []step{{attempts: 4, failures: 4, want: ""}},
+		},
+		{
+			name:  "below failure ratio",
+			steps: []step{{attempts: 10, failures: 4, want: ""}},
+		},
+		{
+			name: "fires and is rate limited",
+			steps: []step{
+				{attempts: 10, failures: 5, want: "firing"},
+				{offset: 10 * time.Second, want: ""},
+				{offset: 60 * time.Second, want: "firing"},
+			},
+		},
+		{
+			name:         "sends resolved",
+			sendResolved: true,
+			steps: []step{
+				{attempts: 10, failures: 10, want: "firing"},
+				{offset: 301 * time.Second, want: "resolved"},
+			},
+		},
+		{
+			name: "resolved not sent by default",
+			steps: []step{
+				{attempts: 10, failures: 10, want: "firing"},
+				{offset: 301 * time.Second, want: ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var received []WebhookPayload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var payload WebhookPayload
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					t.Errorf("failed to decode webhook payload: %v", err)
+				}
+				mu.Lock()
+				received = append(received, payload)
+				mu.Unlock()
+			}))
+			defer server.Close()
+
+			m := newTestMonitor(t, config.MetaAlertConfig{
+				WindowSeconds:      300,
+				FailureRatio:       0.5,
+				MinAttempts:        5,
+				MinIntervalSeconds: 60,
+				WebhookURL:         server.URL,
+				SendResolved:       tt.sendResolved,
+			})
+
+			for i, s := range tt.steps {
+				now := start.Add(s.offset)
+				for n := 0; n < s.attempts; n++ {
+					m.record(now, n < s.failures)
+				}
+
+				mu.Lock()
+				before := len(received)
+				mu.Unlock()
+
+				m.evaluate(context.Background(), now)
+
+				mu.Lock()
+				got := ""
+				if len(received) > before {
+					got = received[len(received)-1].Status
+				}
+				mu.Unlock()
+				if got != s.want {
+					t.Errorf("step %d: webhook status = %q, want %q", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      bool
+		err          error
+		wantAttempts int
+		wantFailures int
+	}{
+		{"disabled", false, errors.New("boom"), 0, 0},
+		{"records success", true, nil, 1, 0},
+		{"records failure", true, errors.New("boom"), 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metaCfg := config.MetaAlertConfig{WindowSeconds: 60}
+			if tt.enabled {
+				metaCfg.WebhookURL = "http://127.0.0.1/unused"
+			}
+			m := newTestMonitor(t, metaCfg)
+
+			client := m.Wrap(&fakeSNSClient{err: tt.err})
+			if _, err := client.PublishToSNS(context.Background(), "topic", "message"); err != tt.err {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+
+			attempts, failures := m.stats(time.Now())
+			if attempts != tt.wantAttempts || failures != tt.wantFailures {
+				t.Errorf("stats = %d/%d, want %d/%d", failures, attempts, tt.wantFailures, tt.wantAttempts)
+			}
+		})
+	}
+}
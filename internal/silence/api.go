@@ -0,0 +1,87 @@
+package silence
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/internal/web"
+	log "github.com/sirupsen/logrus"
+)
+
+type silenceResponse struct {
+	Silence
+	Status string `json:"status"`
+}
+
+// RegisterHandlers adds the /api/v1/silences endpoints to mux.
+func RegisterHandlers(mux *http.ServeMux, store *Store) {
+	mux.HandleFunc("GET /api/v1/silences", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		silences := store.List()
+		response := make([]silenceResponse, 0, len(silences))
+		for _, sil := range silences {
+			response = append(response, silenceResponse{Silence: sil, Status: sil.Status(now)})
+		}
+		web.WriteJSON(w, http.StatusOK, response)
+	})
+
+	mux.HandleFunc("GET /api/v1/silences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		sil, err := store.Get(r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		web.WriteJSON(w, http.StatusOK, silenceResponse{Silence: sil, Status: sil.Status(time.Now())})
+	})
+
+	mux.HandleFunc("POST /api/v1/silences", func(w http.ResponseWriter, r *http.Request) {
+		setSilence(w, r, store, "")
+	})
+
+	mux.HandleFunc("PUT /api/v1/silences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		setSilence(w, r, store, r.PathValue("id"))
+	})
+
+	mux.HandleFunc("DELETE /api/v1/silences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if err := store.Delete(id); err != nil {
+			writeError(w, err)
+			return
+		}
+		log.Infof("Silence %s deleted", id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func setSilence(w http.ResponseWriter, r *http.Request, store *Store, id string) {
+	var sil Silence
+	if err := json.NewDecoder(r.Body).Decode(&sil); err != nil {
+		web.WriteError(w, http.StatusBadRequest, "invalid silence: "+err.Error())
+		return
+	}
+	if id != "" {
+		sil.ID = id
+	}
+
+	id, err := store.Set(sil)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	log.Infof("Silence %s set by %s: %v until %s (%s)", id, sil.CreatedBy, sil.Matchers, sil.EndsAt.Format(time.RFC3339), sil.Comment)
+	web.WriteJSON(w, http.StatusOK, map[string]string{"silenceId": id})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrInvalid):
+		status = http.StatusBadRequest
+	}
+	web.WriteError(w, status, err.Error())
+}
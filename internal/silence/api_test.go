@@ -0,0 +1,69 @@
+package silence
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI(t *testing.T) {
+	store, err := NewStore("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, store)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return recorder
+	}
+
+	endsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	created := do(http.MethodPost, "/api/v1/silences", `{"matchers":["alertname=\"HighLoad\""],"endsAt":"`+endsAt+`","createdBy":"ops"}`)
+	if created.Code != http.StatusOK {
+		t.Fatalf("POST status = %d: %s", created.Code, created.Body.String())
+	}
+	var createResponse struct {
+		SilenceID string `json:"silenceId"`
+	}
+	if err := json.Unmarshal(created.Body.Bytes(), &createResponse); err != nil || createResponse.SilenceID == "" {
+		t.Fatalf("POST response = %s (%v), want a silence ID", created.Body.String(), err)
+	}
+	id := createResponse.SilenceID
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"list", http.MethodGet, "/api/v1/silences", "", http.StatusOK, `"status":"active"`},
+		{"get", http.MethodGet, "/api/v1/silences/" + id, "", http.StatusOK, `"createdBy":"ops"`},
+		{"get unknown", http.MethodGet, "/api/v1/silences/unknown", "", http.StatusNotFound, "silence not found"},
+		{"create malformed", http.MethodPost, "/api/v1/silences", `{`, http.StatusBadRequest, "invalid silence"},
+		{"create invalid", http.MethodPost, "/api/v1/silences", `{"matchers":[],"endsAt":"` + endsAt + `"}`, http.StatusBadRequest, "at least one matcher is required"},
+		{"update", http.MethodPut, "/api/v1/silences/" + id, `{"matchers":["alertname=\"DiskFull\""],"endsAt":"` + endsAt + `"}`, http.StatusOK, id},
+		{"update unknown", http.MethodPut, "/api/v1/silences/unknown", `{"matchers":["alertname=\"DiskFull\""],"endsAt":"` + endsAt + `"}`, http.StatusNotFound, "silence not found"},
+		{"delete", http.MethodDelete, "/api/v1/silences/" + id, "", http.StatusNoContent, ""},
+		{"delete again", http.MethodDelete, "/api/v1/silences/" + id, "", http.StatusNotFound, "silence not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := do(tt.method, tt.path, tt.body)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", recorder.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package silence
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	SilencesActive = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sns_silences_active",
+			Help: "Number of currently active local silences",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(SilencesActive)
+}
@@ -0,0 +1,280 @@
+package silence
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/internal/atomicfile"
+	"github.com/maks3201/sns-alert-service/internal/matchers"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrNotFound is returned for operations on unknown silence IDs.
+	ErrNotFound = errors.New("silence not found")
+	// ErrInvalid is returned when a silence fails validation.
+	ErrInvalid = errors.New("invalid silence")
+)
+
+// Silence mutes all alerts matching its matchers between StartsAt and EndsAt.
+type Silence struct {
+	ID        string    `json:"id"`
+	Matchers  []string  `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	matchers matchers.Matchers
+}
+
+// Active reports whether the silence is in effect at the given time.
+func (s *Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Status returns "pending", "active" or "expired".
+func (s *Silence) Status(now time.Time) string {
+	switch {
+	case now.Before(s.StartsAt):
+		return "pending"
+	case s.Active(now):
+		return "active"
+	default:
+		return "expired"
+	}
+}
+
+func (s *Silence) validate() error {
+	if len(s.Matchers) == 0 {
+		return errors.New("at least one matcher is required")
+	}
+	ms, err := matchers.ParseAll(s.Matchers)
+	if err != nil {
+		return err
+	}
+	if s.EndsAt.IsZero() {
+		return errors.New("endsAt is required")
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return errors.New("endsAt must be after startsAt")
+	}
+	s.matchers = ms
+	return nil
+}
+
+// Store keeps the local silences and persists them to a file, if configured.
+type Store struct {
+	path      string
+	retention time.Duration
+
+	mu       sync.RWMutex
+	silences map[string]*Silence
+}
+
+// NewStore loads the silences from path. An empty path yields an in-memory
+// store. Expired silences are kept for the retention period before being
+// garbage collected.
+func NewStore(path string, retention time.Duration) (*Store, error) {
+	s := &Store{
+		path:      path,
+		retention: retention,
+		silences:  make(map[string]*Silence),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read silences file '%s': %v", path, err)
+	}
+
+	var silences []*Silence
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &silences); err != nil {
+			return nil, fmt.Errorf("failed to parse silences file '%s': %v", path, err)
+		}
+	}
+	for _, sil := range silences {
+		if err := sil.validate(); err != nil {
+			log.Warnf("Skipping invalid silence %s from %s: %v", sil.ID, path, err)
+			continue
+		}
+		s.silences[sil.ID] = sil
+	}
+
+	log.Infof("Loaded %d silences from %s", len(s.silences), path)
+	s.updateMetrics(time.Now())
+	return s, nil
+}
+
+// List returns all silences ordered by end time.
+func (s *Store) List() []Silence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Silence, 0, len(s.silences))
+	for _, sil := range s.silences {
+		result = append(result, *sil)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].EndsAt.Before(result[j].EndsAt) })
+	return result
+}
+
+// Get returns the silence with the given ID.
+func (s *Store) Get(id string) (Silence, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sil, ok := s.silences[id]
+	if !ok {
+		return Silence{}, ErrNotFound
+	}
+	return *sil, nil
+}
+
+// Set creates a silence, or replaces an existing one if its ID is set, and
+// returns the silence ID.
+func (s *Store) Set(sil Silence) (string, error) {
+	now := time.Now()
+	if sil.StartsAt.IsZero() {
+		sil.StartsAt = now
+	}
+	if err := sil.validate(); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sil.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return "", err
+		}
+		sil.ID = id
+	} else if _, ok := s.silences[sil.ID]; !ok {
+		return "", ErrNotFound
+	}
+	sil.UpdatedAt = now
+
+	s.silences[sil.ID] = &sil
+	s.updateMetrics(now)
+	return sil.ID, s.save()
+}
+
+// Delete removes the silence with the given ID.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.silences[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.silences, id)
+	s.updateMetrics(time.Now())
+	return s.save()
+}
+
+// Mutes reports whether an active silence matches the label set.
+func (s *Store) Mutes(labels map[string]string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for _, sil := range s.silences {
+		if sil.Active(now) && sil.matchers.Matches(labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run periodically garbage collects expired silences until ctx is cancelled.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if removed := s.gc(now); removed > 0 {
+				log.Infof("Garbage collected %d expired silences", removed)
+			}
+		}
+	}
+}
+
+func (s *Store) gc(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for id, sil := range s.silences {
+		if now.Sub(sil.EndsAt) > s.retention {
+			delete(s.silences, id)
+			removed++
+		}
+	}
+	s.updateMetrics(now)
+
+	if removed > 0 {
+		if err := s.save(); err != nil {
+			log.Errorf("Error saving silences: %v", err)
+		}
+	}
+	return removed
+}
+
+func (s *Store) updateMetrics(now time.Time) {
+	active := 0
+	for _, sil := range s.silences {
+		if sil.Active(now) {
+			active++
+		}
+	}
+	SilencesActive.Set(float64(active))
+}
+
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	silences := make([]*Silence, 0, len(s.silences))
+	for _, sil := range s.silences {
+		silences = append(silences, sil)
+	}
+	data, err := json.MarshalIndent(silences, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode silences: %v", err)
+	}
+
+	if err := atomicfile.Write(s.path, data); err != nil {
+		return fmt.Errorf("failed to write silences file: %v", err)
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate silence ID: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
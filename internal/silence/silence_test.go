@@ -0,0 +1,155 @@
+package silence
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSilenceStatus(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sil := Silence{StartsAt: now, EndsAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{now.Add(-time.Second), "pending"},
+		{now, "active"},
+		{now.Add(59 * time.Minute), "active"},
+		{now.Add(time.Hour), "expired"},
+	}
+	for _, tt := range tests {
+		if got := sil.Status(tt.at); got != tt.want {
+			t.Errorf("Status(%s) = %q, want %q", tt.at.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
+
+func TestStoreSetValidation(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		silence Silence
+		wantErr error
+	}{
+		{"valid", Silence{Matchers: []string{`alertname="HighLoad"`}, EndsAt: now.Add(time.Hour)}, nil},
+		{"no matchers", Silence{EndsAt: now.Add(time.Hour)}, ErrInvalid},
+		{"invalid matcher", Silence{Matchers: []string{`alertname`}, EndsAt: now.Add(time.Hour)}, ErrInvalid},
+		{"no end", Silence{Matchers: []string{`alertname="HighLoad"`}}, ErrInvalid},
+		{"ends before start", Silence{Matchers: []string{`alertname="HighLoad"`}, StartsAt: now, EndsAt: now.Add(-time.Hour)}, ErrInvalid},
+		{"unknown ID", Silence{ID: "unknown", Matchers: []string{`alertname="HighLoad"`}, EndsAt: now.Add(time.Hour)}, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewStore("", time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, err := store.Set(tt.silence)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Set() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && id == "" {
+				t.Error("Set() returned an empty ID")
+			}
+		})
+	}
+}
+
+func TestStoreMutes(t *testing.T) {
+	now := time.Now()
+	store, err := NewStore("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sil := range []Silence{
+		{Matchers: []string{`alertname="HighLoad"`, `env="prod"`}, EndsAt: now.Add(time.Hour)},
+		{Matchers: []string{`alertname="DiskFull"`}, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+		{Matchers: []string{`alertname="Expired"`}, StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)},
+		{Matchers: []string{`team=~"db|storage"`}, EndsAt: now.Add(time.Hour)},
+	} {
+		if _, err := store.Set(sil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{"all matchers", map[string]string{"alertname": "HighLoad", "env": "prod"}, true},
+		{"some matchers", map[string]string{"alertname": "HighLoad", "env": "dev"}, false},
+		{"pending silence", map[string]string{"alertname": "DiskFull"}, false},
+		{"expired silence", map[string]string{"alertname": "Expired"}, false},
+		{"regex matcher", map[string]string{"alertname": "SlowQueries", "team": "db"}, true},
+		{"no silence", map[string]string{"alertname": "Other"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := store.Mutes(tt.labels); got != tt.want {
+				t.Errorf("Mutes(%v) = %v, want %v", tt.labels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreGC(t *testing.T) {
+	now := time.Now()
+	store, err := NewStore("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, _ := store.Set(Silence{Matchers: []string{`alertname="A"`}, EndsAt: now.Add(time.Hour)})
+	retained, _ := store.Set(Silence{Matchers: []string{`alertname="B"`}, StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-30 * time.Minute)})
+	collected, _ := store.Set(Silence{Matchers: []string{`alertname="C"`}, StartsAt: now.Add(-3 * time.Hour), EndsAt: now.Add(-2 * time.Hour)})
+
+	if removed := store.gc(now); removed != 1 {
+		t.Errorf("gc() removed %d silences, want 1", removed)
+	}
+	for id, want := range map[string]bool{active: true, retained: true, collected: false} {
+		if _, err := store.Get(id); (err == nil) != want {
+			t.Errorf("Get(%s) error = %v, want silence kept = %v", id, err, want)
+		}
+	}
+}
+
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "silences.json")
+	now := time.Now().Truncate(time.Second)
+
+	store, err := NewStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := store.Set(Silence{Matchers: []string{`alertname="HighLoad"`}, EndsAt: now.Add(time.Hour), CreatedBy: "ops", Comment: "maintenance"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := store.Set(Silence{Matchers: []string{`alertname="DiskFull"`}, EndsAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	silences := reloaded.List()
+	if len(silences) != 1 || silences[0].ID != kept || silences[0].CreatedBy != "ops" || !silences[0].EndsAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("reloaded silences = %+v, want only %s", silences, kept)
+	}
+	if !reloaded.Mutes(map[string]string{"alertname": "HighLoad"}) {
+		t.Error("reloaded silence does not mute matching alerts")
+	}
+	if err := reloaded.Delete(deleted); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() error = %v, want %v", err, ErrNotFound)
+	}
+}
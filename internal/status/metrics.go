@@ -0,0 +1,19 @@
+package health
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	TopicUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sns_topic_up",
+			Help: "Whether the last health check of each SNS topic succeeded (1) or failed (0)",
+		},
+		[]string{"topic"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(TopicUp)
+}
@@ -2,36 +2,244 @@ package health
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/maks3201/sns-alert-service/config"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusUnknown = "unknown"
+)
+
 type SNSClient interface {
 	CheckSNSConnection(ctx context.Context) error
+	TopicExists(ctx context.Context, topicArn string) (bool, error)
+	CheckPublishPermission(ctx context.Context, topicArn string) error
+}
+
+// Pipeline reports the state of the alert processing pipeline.
+type Pipeline interface {
+	// LastTick returns when the batching loop last ran.
+	LastTick() time.Time
+	// QueueUtilization returns the fill level of the alert queue from 0 to 1.
+	QueueUtilization() float64
+}
+
+// ComponentStatus is the state of a single component in the health
+// response.
+type ComponentStatus struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Response is the JSON body of the health endpoints.
+type Response struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+	// Topics holds the result of the checks of each configured topic.
+	Topics map[string]ComponentStatus `json:"topics,omitempty"`
+}
+
+// Checker answers liveness and readiness probes. The AWS checks are run in
+// the background and cached, so that probes do not call the AWS API and a
+// short SNS outage does not fail liveness.
+type Checker struct {
+	snsClient SNSClient
+	pipeline  Pipeline
+	topics    []config.SNSTopicConfig
+	cfg       config.HealthConfig
+
+	mu           sync.RWMutex
+	snsStatus    ComponentStatus
+	topicsStatus ComponentStatus
+	topicStatus  map[string]ComponentStatus
 }
 
-func HealthHandler(w http.ResponseWriter, r *http.Request, snsClient SNSClient) {
-	if snsClient == nil {
-		log.Error("SNSClient is not initialized")
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+func NewChecker(cfg config.Config, snsClient SNSClient, pipeline Pipeline) *Checker {
+	return &Checker{
+		snsClient:    snsClient,
+		pipeline:     pipeline,
+		topics:       cfg.Topics,
+		cfg:          cfg.Health,
+		snsStatus:    ComponentStatus{Status: StatusUnknown},
+		topicsStatus: ComponentStatus{Status: StatusUnknown},
+		topicStatus:  make(map[string]ComponentStatus),
 	}
+}
 
-	// Проверка соединения с AWS SNS
-	err := snsClient.CheckSNSConnection(r.Context())
-	if err != nil {
+// Run checks AWS SNS immediately and then on every interval until ctx is
+// cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(c.cfg.CheckIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		c.checkAWS(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) checkAWS(ctx context.Context) {
+	now := time.Now()
+
+	sns := ComponentStatus{Status: StatusOK, CheckedAt: now}
+	if err := c.snsClient.CheckSNSConnection(ctx); err != nil {
 		log.Errorf("Health check failed: %v", err)
-		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
-		return
+		sns = ComponentStatus{Status: StatusFailed, Error: err.Error(), CheckedAt: now}
+	} else {
+		log.Debug("Successfully connected to AWS SNS during health check")
+	}
+
+	topicStatus := make(map[string]ComponentStatus, len(c.topics))
+	var failed []string
+	for _, topic := range c.topics {
+		status := c.checkTopic(ctx, topic, now)
+		topicStatus[topic.Name] = status
+
+		up := 0.0
+		if status.Status == StatusOK {
+			up = 1
+		} else {
+			log.Errorf("Health check of topic %s failed: %s", topic.Name, status.Error)
+			failed = append(failed, topic.Name)
+		}
+		TopicUp.WithLabelValues(topic.Name).Set(up)
+	}
+
+	topics := ComponentStatus{Status: StatusOK, CheckedAt: now}
+	if len(failed) > 0 {
+		topics = ComponentStatus{Status: StatusFailed, Error: fmt.Sprintf("failed topics: %s", strings.Join(failed, ", ")), CheckedAt: now}
 	}
 
-	// Логируем успешное подключение в режиме debug, если ошибок нет
-	log.Debug("Successfully connected to AWS SNS during health check")
+	c.mu.Lock()
+	c.snsStatus = sns
+	c.topicsStatus = topics
+	c.topicStatus = topicStatus
+	c.mu.Unlock()
+}
+
+// checkTopic verifies that the topic exists and, if enabled, that the
+// client is allowed to publish to it.
+func (c *Checker) checkTopic(ctx context.Context, topic config.SNSTopicConfig, now time.Time) ComponentStatus {
+	exists, err := c.snsClient.TopicExists(ctx, topic.ARN)
+	if err != nil {
+		return ComponentStatus{Status: StatusFailed, Error: err.Error(), CheckedAt: now}
+	}
+	if !exists {
+		return ComponentStatus{Status: StatusFailed, Error: fmt.Sprintf("topic with ARN %s does not exist", topic.ARN), CheckedAt: now}
+	}
+
+	if c.cfg.CheckPublishPermission {
+		if err := c.snsClient.CheckPublishPermission(ctx, topic.ARN); err != nil {
+			return ComponentStatus{Status: StatusFailed, Error: err.Error(), CheckedAt: now}
+		}
+	}
+	return ComponentStatus{Status: StatusOK, CheckedAt: now}
+}
+
+func (c *Checker) batchLoop(now time.Time) ComponentStatus {
+	timeout := time.Duration(c.cfg.BatchLoopTimeoutSeconds) * time.Second
+	lastTick := c.pipeline.LastTick()
+	if now.Sub(lastTick) > timeout {
+		return ComponentStatus{
+			Status:    StatusFailed,
+			Error:     fmt.Sprintf("batch loop has not run for %s", now.Sub(lastTick).Round(time.Second)),
+			CheckedAt: lastTick,
+		}
+	}
+	return ComponentStatus{Status: StatusOK, CheckedAt: lastTick}
+}
+
+func (c *Checker) queue(now time.Time) ComponentStatus {
+	utilization := c.pipeline.QueueUtilization()
+	if utilization >= c.cfg.MaxQueueUtilization {
+		return ComponentStatus{
+			Status:    StatusFailed,
+			Error:     fmt.Sprintf("alert queue is %.0f%% full", utilization*100),
+			CheckedAt: now,
+		}
+	}
+	return ComponentStatus{Status: StatusOK, CheckedAt: now}
+}
+
+// Liveness reports whether the process is alive and the batching loop is
+// running.
+func (c *Checker) Liveness() Response {
+	return newResponse(map[string]ComponentStatus{
+		"batch_loop": c.batchLoop(time.Now()),
+	})
+}
+
+// Readiness reports whether alerts can be accepted and delivered: SNS is
+// reachable, all topics pass their checks and the alert queue is not
+// overloaded. The result of each topic's checks is included.
+func (c *Checker) Readiness() Response {
+	now := time.Now()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	response := newResponse(map[string]ComponentStatus{
+		"batch_loop": c.batchLoop(now),
+		"queue":      c.queue(now),
+		"sns":        c.snsStatus,
+		"topics":     c.topicsStatus,
+	})
+
+	response.Topics = make(map[string]ComponentStatus, len(c.topics))
+	for _, topic := range c.topics {
+		status, ok := c.topicStatus[topic.Name]
+		if !ok {
+			status = ComponentStatus{Status: StatusUnknown}
+		}
+		response.Topics[topic.Name] = status
+	}
+	return response
+}
+
+func newResponse(components map[string]ComponentStatus) Response {
+	status := StatusOK
+	for _, component := range components {
+		if component.Status != StatusOK {
+			status = StatusFailed
+		}
+	}
+	return Response{Status: status, Components: components}
+}
+
+// LivenessHandler serves /-/healthy.
+func (c *Checker) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, c.Liveness())
+}
+
+// ReadinessHandler serves /-/ready.
+func (c *Checker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, c.Readiness())
+}
+
+func writeResponse(w http.ResponseWriter, response Response) {
+	status := http.StatusOK
+	if response.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
 
-	// Возвращаем успешный HTTP-ответ
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write([]byte("healthy")); err != nil {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Errorf("Error writing response: %v", err)
 	}
 }
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maks3201/sns-alert-service/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(exporter, config.TracingConfig{ServiceName: "test", SampleRatio: 1})
+	defer provider.Shutdown(context.Background())
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	const parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name        string
+		traceparent string
+		status      int
+		wantCode    codes.Code
+	}{
+		{"new trace", "", http.StatusOK, codes.Unset},
+		{"continued trace", "00-" + parentTraceID + "-00f067aa0ba902b7-01", http.StatusOK, codes.Unset},
+		{"client error", "", http.StatusBadRequest, codes.Unset},
+		{"server error", "", http.StatusServiceUnavailable, codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			handler := Middleware("/alert", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/alert", nil)
+			if tt.traceparent != "" {
+				r.Header.Set("traceparent", tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if err := provider.ForceFlush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+
+			if span.Name != "POST /alert" {
+				t.Errorf("span name = %q, want %q", span.Name, "POST /alert")
+			}
+			if tt.traceparent != "" && span.SpanContext.TraceID().String() != parentTraceID {
+				t.Errorf("trace ID = %s, want the propagated %s", span.SpanContext.TraceID(), parentTraceID)
+			}
+			if span.Status.Code != tt.wantCode {
+				t.Errorf("status = %v, want %v", span.Status.Code, tt.wantCode)
+			}
+			want := attribute.Int("http.response.status_code", tt.status)
+			found := false
+			for _, attr := range span.Attributes {
+				if attr == want {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("attributes %v do not contain %v", span.Attributes, want)
+			}
+		})
+	}
+}
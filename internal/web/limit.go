@@ -0,0 +1,50 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrorResponse is the JSON body of error responses.
+type ErrorResponse struct {
+	Status  string   `json:"status"`
+	Error   string   `json:"error"`
+	Details []string `json:"details,omitempty"`
+}
+
+// WriteJSON writes v as a JSON response with the given status.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Error writing response: %v", err)
+	}
+}
+
+// WriteError writes a JSON error response.
+func WriteError(w http.ResponseWriter, status int, message string, details ...string) {
+	WriteJSON(w, status, ErrorResponse{Status: "error", Error: message, Details: details})
+}
+
+// WriteBodyTooLarge writes the 413 response for a request body over limit.
+func WriteBodyTooLarge(w http.ResponseWriter, limit int64) {
+	WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds the limit of %d bytes", limit))
+}
+
+// LimitRequestBody rejects requests whose body is larger than limit. Bodies
+// without a declared length are wrapped with http.MaxBytesReader, so that
+// handlers reading them get an *http.MaxBytesError.
+func LimitRequestBody(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			Logger(r.Context()).Warnf("Rejected request to %s from %s: body of %d bytes exceeds the limit", r.URL.Path, r.RemoteAddr, r.ContentLength)
+			WriteBodyTooLarge(w, limit)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,221 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maks3201/sns-alert-service/config"
+	log "github.com/sirupsen/logrus"
+)
+
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// TLSManager serves the server certificate and client CA pool from disk and
+// reloads them when the files change. New handshakes pick up the reloaded
+// files while established connections are left untouched.
+type TLSManager struct {
+	cfg          config.TLSConfig
+	minVersion   uint16
+	cipherSuites []uint16
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+// NewTLSManager validates the TLS configuration and loads the certificates.
+func NewTLSManager(cfg config.TLSConfig) (*TLSManager, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("both cert_file and key_file are required")
+	}
+
+	if len(cfg.ClientAllowedSubjects) > 0 && cfg.ClientCAFile == "" {
+		return nil, errors.New("client_allowed_subjects requires client_ca_file, without it client certificates are not verified")
+	}
+
+	m := &TLSManager{
+		cfg:        cfg,
+		minVersion: tls.VersionTLS12,
+	}
+
+	if cfg.MinVersion != "" {
+		version, ok := tlsVersions[strings.ToUpper(cfg.MinVersion)]
+		if !ok {
+			return nil, fmt.Errorf("unknown min_version '%s'", cfg.MinVersion)
+		}
+		m.minVersion = version
+	}
+
+	for _, name := range cfg.CipherSuites {
+		id, err := cipherSuiteID(name)
+		if err != nil {
+			return nil, err
+		}
+		m.cipherSuites = append(m.cipherSuites, id)
+	}
+
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TLSConfig returns the server TLS configuration. The certificate and
+// client CAs are resolved per handshake, so reloads take effect without a
+// restart.
+func (m *TLSManager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: m.minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+
+			cfg := &tls.Config{
+				NextProtos:   []string{"h2", "http/1.1"},
+				MinVersion:   m.minVersion,
+				CipherSuites: m.cipherSuites,
+				Certificates: []tls.Certificate{*m.cert},
+			}
+			if m.clientCAs != nil {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = m.clientCAs
+				cfg.VerifyConnection = m.verifyClientSubject
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// Run checks the certificate files for changes until ctx is cancelled.
+func (m *TLSManager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !m.changed() {
+				continue
+			}
+			if err := m.reload(); err != nil {
+				log.Errorf("Failed to reload TLS certificates, keeping the previous ones: %v", err)
+				continue
+			}
+			log.Info("TLS certificates reloaded")
+		}
+	}
+}
+
+func (m *TLSManager) files() []string {
+	files := []string{m.cfg.CertFile, m.cfg.KeyFile}
+	if m.cfg.ClientCAFile != "" {
+		files = append(files, m.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (m *TLSManager) changed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, file := range m.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			log.Errorf("Failed to check TLS file '%s': %v", file, err)
+			continue
+		}
+		if !info.ModTime().Equal(m.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *TLSManager) reload() error {
+	modTimes := make(map[string]time.Time)
+	for _, file := range m.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS file '%s': %v", file, err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(m.cfg.CertFile, m.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %v", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if m.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(m.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file '%s': %v", m.cfg.ClientCAFile, err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file '%s'", m.cfg.ClientCAFile)
+		}
+	}
+
+	m.mu.Lock()
+	m.cert = &cert
+	m.clientCAs = clientCAs
+	m.modTimes = modTimes
+	m.mu.Unlock()
+	return nil
+}
+
+// verifyClientSubject accepts client certificates whose common name or one
+// of the subject alternative names is in the allowed list.
+func (m *TLSManager) verifyClientSubject(state tls.ConnectionState) error {
+	if len(m.cfg.ClientAllowedSubjects) == 0 || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	names := []string{leaf.Subject.CommonName}
+	names = append(names, leaf.DNSNames...)
+	names = append(names, leaf.EmailAddresses...)
+	for _, uri := range leaf.URIs {
+		names = append(names, uri.String())
+	}
+
+	for _, allowed := range m.cfg.ClientAllowedSubjects {
+		for _, name := range names {
+			if name == allowed {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("client certificate subject %q is not allowed", leaf.Subject.CommonName)
+}
+
+func cipherSuiteID(name string) (uint16, error) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, nil
+		}
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == name {
+			return 0, fmt.Errorf("cipher suite '%s' is insecure", name)
+		}
+	}
+	return 0, fmt.Errorf("unknown cipher suite '%s'", name)
+}
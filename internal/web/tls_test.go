@@ -0,0 +1,56 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maks3201/sns-alert-service/config"
+)
+
+func TestNewTLSManagerValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.TLSConfig
+		wantErr string
+	}{
+		{
+			name:    "missing key",
+			cfg:     config.TLSConfig{CertFile: "tls.crt"},
+			wantErr: "both cert_file and key_file are required",
+		},
+		{
+			name:    "allowed subjects without client CA",
+			cfg:     config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientAllowedSubjects: []string{"alertmanager"}},
+			wantErr: "client_allowed_subjects requires client_ca_file",
+		},
+		{
+			name:    "unknown min version",
+			cfg:     config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", MinVersion: "SSL3"},
+			wantErr: "unknown min_version",
+		},
+		{
+			name:    "unknown cipher suite",
+			cfg:     config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", CipherSuites: []string{"TLS_NONE"}},
+			wantErr: "unknown cipher suite",
+		},
+		{
+			name:    "insecure cipher suite",
+			cfg:     config.TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			wantErr: "is insecure",
+		},
+		{
+			name:    "secure cipher suite",
+			cfg:     config.TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+			wantErr: "failed to read TLS file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTLSManager(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewTLSManager() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}